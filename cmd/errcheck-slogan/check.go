@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// ErrorsPackage is the import path of the slogan errors package.
+const ErrorsPackage = "code.internetisalie.net/slogan/pkg/errors"
+
+// wrappingPackages construct or wrap errors, so returning their results is not a leak.
+var wrappingPackages = map[string]bool{
+	"errors":      true,
+	"fmt":         true,
+	ErrorsPackage: true,
+}
+
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+type Diagnostic struct {
+	Pos     token.Position
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Pos, d.Message)
+}
+
+type checker struct {
+	fset        *token.FileSet
+	pkg         *types.Package
+	info        *types.Info
+	diagnostics []Diagnostic
+}
+
+// Check reports error-wrapping hygiene problems in a type-checked package.
+func Check(fset *token.FileSet, files []*ast.File, pkg *types.Package, info *types.Info) []Diagnostic {
+	c := &checker{
+		fset: fset,
+		pkg:  pkg,
+		info: info,
+	}
+
+	for _, file := range files {
+		c.checkFile(file)
+	}
+
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		a, b := c.diagnostics[i].Pos, c.diagnostics[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+
+	return c.diagnostics
+}
+
+func (c *checker) report(pos token.Pos, format string, args ...any) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		Pos:     c.fset.Position(pos),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *checker) checkFile(file *ast.File) {
+	for _, decl := range file.Decls {
+		sentinelsAllowed := false
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.VAR {
+			sentinelsAllowed = true
+		}
+
+		ast.Inspect(decl, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.CallExpr:
+				c.checkErrorf(n)
+				if !sentinelsAllowed {
+					c.checkNewSentinel(n)
+				}
+			case *ast.BinaryExpr:
+				c.checkSentinelComparison(n)
+			case *ast.SwitchStmt:
+				c.checkSentinelSwitch(n)
+			case *ast.FuncDecl:
+				if n.Body != nil {
+					c.checkReturns(c.info.Defs[n.Name].Type().(*types.Signature), n.Body)
+				}
+			case *ast.FuncLit:
+				c.checkReturns(c.info.Types[n].Type.(*types.Signature), n.Body)
+			}
+			return true
+		})
+	}
+}
+
+// callee returns the function or method called, if it is statically known.
+func (c *checker) callee(call *ast.CallExpr) *types.Func {
+	var ident *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return nil
+	}
+	fn, _ := c.info.Uses[ident].(*types.Func)
+	return fn
+}
+
+func isError(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	if basic, ok := t.(*types.Basic); ok && basic.Kind() == types.UntypedNil {
+		return false
+	}
+	return types.Implements(t, errorType)
+}
+
+// checkErrorf reports fmt.Errorf calls formatting an error without %w.
+func (c *checker) checkErrorf(call *ast.CallExpr) {
+	fn := c.callee(call)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != "fmt" || fn.Name() != "Errorf" {
+		return
+	}
+	if len(call.Args) < 2 {
+		return
+	}
+
+	format := c.info.Types[call.Args[0]].Value
+	if format == nil || format.Kind() != constant.String {
+		return
+	}
+	if strings.Contains(constant.StringVal(format), "%w") {
+		return
+	}
+
+	for _, arg := range call.Args[1:] {
+		if isError(c.info.TypeOf(arg)) {
+			c.report(call.Pos(), "fmt.Errorf formats error %s without %%w", types.ExprString(arg))
+			return
+		}
+	}
+}
+
+// checkNewSentinel reports NewSentinel calls outside package-level var declarations.
+func (c *checker) checkNewSentinel(call *ast.CallExpr) {
+	fn := c.callee(call)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != ErrorsPackage || fn.Name() != "NewSentinel" {
+		return
+	}
+	if c.pkg.Path() == ErrorsPackage {
+		return
+	}
+	c.report(call.Pos(), "errors.NewSentinel called outside a package-level var declaration")
+}
+
+// sentinel returns the package-level error variable referenced by expr, if any.
+func (c *checker) sentinel(expr ast.Expr) *types.Var {
+	var ident *ast.Ident
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		ident = e
+	case *ast.SelectorExpr:
+		ident = e.Sel
+	default:
+		return nil
+	}
+
+	v, ok := c.info.Uses[ident].(*types.Var)
+	if !ok || v.Pkg() == nil || v.Parent() != v.Pkg().Scope() {
+		return nil
+	}
+	if !isError(v.Type()) {
+		return nil
+	}
+	return v
+}
+
+func sentinelName(v *types.Var) string {
+	return v.Pkg().Name() + "." + v.Name()
+}
+
+// checkSentinelComparison reports == and != comparisons against sentinel errors.
+func (c *checker) checkSentinelComparison(expr *ast.BinaryExpr) {
+	if expr.Op != token.EQL && expr.Op != token.NEQ {
+		return
+	}
+
+	for _, operands := range [][2]ast.Expr{{expr.X, expr.Y}, {expr.Y, expr.X}} {
+		if v := c.sentinel(operands[0]); v != nil && isError(c.info.TypeOf(operands[1])) {
+			c.report(expr.OpPos, "comparison with sentinel %s using %s; use errors.Is", sentinelName(v), expr.Op)
+			return
+		}
+	}
+}
+
+// checkSentinelSwitch reports switch statements comparing an error against sentinel cases.
+func (c *checker) checkSentinelSwitch(stmt *ast.SwitchStmt) {
+	if stmt.Tag == nil || !isError(c.info.TypeOf(stmt.Tag)) {
+		return
+	}
+
+	for _, clause := range stmt.Body.List {
+		for _, expr := range clause.(*ast.CaseClause).List {
+			if v := c.sentinel(expr); v != nil {
+				c.report(expr.Pos(), "switch case compares sentinel %s using ==; use errors.Is", sentinelName(v))
+			}
+		}
+	}
+}
+
+// foreignCall returns the qualified name of a function from another,
+// non-wrapping package, called by expr.  Interface methods, such as
+// slog.Handler.Handle or context.Context.Err, are not foreign calls: they
+// forward to an implementation that is responsible for its own errors, and
+// their results, such as io.EOF, are often compared by identity.
+func (c *checker) foreignCall(expr ast.Expr) (string, bool) {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+
+	fn := c.callee(call)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg() == c.pkg || wrappingPackages[fn.Pkg().Path()] {
+		return "", false
+	}
+
+	name := fn.Name()
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		if types.IsInterface(recv.Type()) {
+			return "", false
+		}
+		name = strings.TrimPrefix(types.TypeString(recv.Type(), types.RelativeTo(fn.Pkg())), "*") + "." + name
+	}
+	return fn.Pkg().Name() + "." + name, true
+}
+
+// checkReturns reports errors returned unwrapped from calls into other packages.
+func (c *checker) checkReturns(signature *types.Signature, body *ast.BlockStmt) {
+	errorResults := make(map[int]bool)
+	for i := 0; i < signature.Results().Len(); i++ {
+		if isError(signature.Results().At(i).Type()) {
+			errorResults[i] = true
+		}
+	}
+	if len(errorResults) == 0 {
+		return
+	}
+
+	// local error variables most recently assigned from a foreign call
+	origins := make(map[types.Object]string)
+
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncLit:
+			// checked separately, with its own signature
+			return false
+
+		case *ast.AssignStmt:
+			var name string
+			var foreign bool
+			if len(n.Rhs) == 1 {
+				name, foreign = c.foreignCall(n.Rhs[0])
+			}
+			for _, lhs := range n.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				obj := c.info.ObjectOf(ident)
+				if obj == nil || !isError(obj.Type()) {
+					continue
+				}
+				if foreign {
+					origins[obj] = name
+				} else {
+					delete(origins, obj)
+				}
+			}
+
+		case *ast.ReturnStmt:
+			if len(n.Results) == 1 && signature.Results().Len() > 1 {
+				// return f(), where f returns multiple values
+				if name, ok := c.foreignCall(n.Results[0]); ok {
+					c.report(n.Results[0].Pos(), "error from %s returned without wrapping", name)
+				}
+				return true
+			}
+			if len(n.Results) != signature.Results().Len() {
+				return true
+			}
+			for i, result := range n.Results {
+				if !errorResults[i] {
+					continue
+				}
+				if name, ok := c.foreignCall(result); ok {
+					c.report(result.Pos(), "error from %s returned without wrapping", name)
+					continue
+				}
+				if ident, ok := ast.Unparen(result).(*ast.Ident); ok {
+					if name, ok := origins[c.info.ObjectOf(ident)]; ok {
+						c.report(result.Pos(), "error from %s returned without wrapping", name)
+					}
+				}
+			}
+		}
+		return true
+	})
+}
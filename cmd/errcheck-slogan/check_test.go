@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var regexpWant = regexp.MustCompile(`"([^"]*)"`)
+
+func formatDiagnostic(pos token.Position, message string) string {
+	return fmt.Sprintf("%s:%d: %s", filepath.Base(pos.Filename), pos.Line, message)
+}
+
+// wantDiagnostics returns the diagnostics expected by `// want "message"` comments.
+func wantDiagnostics(fset *token.FileSet, files []*ast.File) []string {
+	var result []string
+	for _, file := range files {
+		for _, group := range file.Comments {
+			for _, comment := range group.List {
+				text, ok := strings.CutPrefix(comment.Text, "// want ")
+				if !ok {
+					continue
+				}
+				for _, match := range regexpWant.FindAllStringSubmatch(text, -1) {
+					result = append(result, formatDiagnostic(fset.Position(comment.Pos()), match[1]))
+				}
+			}
+		}
+	}
+	return result
+}
+
+func TestCheck(t *testing.T) {
+	fset := token.NewFileSet()
+	filenames, _ := filepath.Glob(filepath.Join("testdata", "sample", "*.go"))
+
+	files, pkg, info, err := loadPackage(fset, "sample", filenames)
+	assert.NoError(t, err)
+
+	var got []string
+	for _, diagnostic := range Check(fset, files, pkg, info) {
+		got = append(got, formatDiagnostic(diagnostic.Pos, diagnostic.Message))
+	}
+
+	assert.ElementsMatch(t, wantDiagnostics(fset, files), got)
+}
+
+func TestRun_Module(t *testing.T) {
+	if os.Getenv("ERRCHECK_SLOGAN_MODULE") == "" {
+		t.Skip("type checks the whole module; set ERRCHECK_SLOGAN_MODULE=1 to run")
+	}
+
+	stdout, stderr := new(strings.Builder), new(strings.Builder)
+	assert.Equal(t, 0, run([]string{"code.internetisalie.net/slogan/pkg/..."}, stdout, stderr))
+	assert.Empty(t, stdout.String())
+	assert.Empty(t, stderr.String())
+}
@@ -0,0 +1,144 @@
+// Command errcheck-slogan reports error-wrapping hygiene problems:
+//
+//   - fmt.Errorf calls formatting an error argument without %w
+//   - errors from other packages returned without wrapping
+//   - errors.NewSentinel calls outside package-level var declarations
+//   - == and != comparisons against sentinel errors
+//
+// Usage:
+//
+//	errcheck-slogan [packages]
+//
+// Packages are specified as for `go list`, defaulting to the current
+// directory.  Like `go vet`, it exits with status 1 if problems are found.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+type listedPackage struct {
+	Dir        string
+	ImportPath string
+	GoFiles    []string
+	Error      *struct {
+		Err string
+	}
+}
+
+func listPackages(patterns []string) ([]listedPackage, error) {
+	args := append([]string{"list", "-e", "-json=Dir,ImportPath,GoFiles,Error"}, patterns...)
+	cmd := exec.Command("go", args...)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w", err)
+	}
+
+	var result []listedPackage
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var pkg listedPackage
+		if err = decoder.Decode(&pkg); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("go list: %w", err)
+		}
+		result = append(result, pkg)
+	}
+	return result, nil
+}
+
+// loadPackage parses and type-checks the named files of a package.
+func loadPackage(fset *token.FileSet, importPath string, filenames []string) ([]*ast.File, *types.Package, *types.Info, error) {
+	files := make([]*ast.File, 0, len(filenames))
+	for _, filename := range filenames {
+		file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("parse: %w", err)
+		}
+		files = append(files, file)
+	}
+
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+	}
+
+	pkg, err := conf.Check(importPath, fset, files, info)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("type check: %w", err)
+	}
+	return files, pkg, info, nil
+}
+
+func run(patterns []string, stdout, stderr io.Writer) int {
+	packages, err := listPackages(patterns)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	exitCode := 0
+	fset := token.NewFileSet()
+	for _, listed := range packages {
+		if listed.Error != nil {
+			_, _ = fmt.Fprintln(stderr, listed.Error.Err)
+			exitCode = 2
+			continue
+		}
+
+		filenames := make([]string, len(listed.GoFiles))
+		for i, name := range listed.GoFiles {
+			filenames[i] = filepath.Join(listed.Dir, name)
+		}
+
+		files, pkg, info, err := loadPackage(fset, listed.ImportPath, filenames)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s: %v\n", listed.ImportPath, err)
+			exitCode = 2
+			continue
+		}
+
+		for _, diagnostic := range Check(fset, files, pkg, info) {
+			_, _ = fmt.Fprintln(stdout, diagnostic)
+			if exitCode == 0 {
+				exitCode = 1
+			}
+		}
+	}
+
+	return exitCode
+}
+
+func main() {
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "usage: errcheck-slogan [packages]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	os.Exit(run(patterns, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,77 @@
+package sample
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+var ErrSample = errors.NewSentinel("sample")
+
+func errorf(err error) error {
+	_ = fmt.Errorf("wrapped: %w", err)
+	_ = fmt.Errorf("count: %d", 1)
+	return fmt.Errorf("wrapped: %v", err) // want "fmt.Errorf formats error err without %w"
+}
+
+func sentinel() error {
+	return errors.NewSentinel("dynamic") // want "errors.NewSentinel called outside a package-level var declaration"
+}
+
+func compare(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrSample) {
+		return true
+	}
+	switch err {
+	case io.EOF: // want "switch case compares sentinel io.EOF using ==; use errors.Is"
+		return true
+	}
+	return err == ErrSample || io.ErrUnexpectedEOF != err // want "comparison with sentinel sample.ErrSample using ==; use errors.Is" "comparison with sentinel io.ErrUnexpectedEOF using !=; use errors.Is"
+}
+
+func open(name string) (*os.File, error) {
+	return os.Open(name) // want "error from os.Open returned without wrapping"
+}
+
+func closeFile(f *os.File) error {
+	err := f.Close()
+	if err != nil {
+		return err // want "error from os.File.Close returned without wrapping"
+	}
+
+	err = compareAndWrap(err)
+	return err
+}
+
+func compareAndWrap(err error) error {
+	if err != nil {
+		return errors.WrapSentinel(err, "failed")
+	}
+	f := func() error {
+		_, err := os.Stat("x")
+		return err // want "error from os.Stat returned without wrapping"
+	}
+	return f()
+}
+
+type forwardingHandler struct {
+	slog.Handler
+	out io.Writer
+}
+
+func (h forwardingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := h.out.Write([]byte(r.Message)); err != nil {
+		return err
+	}
+	return h.Handler.Handle(ctx, r)
+}
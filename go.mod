@@ -10,11 +10,11 @@ require (
 	github.com/samber/mo v1.13.0
 	github.com/samber/slog-multi v1.2.0
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/sys v0.13.0
 )
 
 require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sys v0.13.0 // indirect
 	golang.org/x/text v0.16.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
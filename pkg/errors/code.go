@@ -0,0 +1,317 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// Code classifies an error independently of its message.
+type Code string
+
+const (
+	CodeUnknown            Code = "unknown"
+	CodeCanceled           Code = "canceled"
+	CodeInvalidArgument    Code = "invalid_argument"
+	CodeDeadlineExceeded   Code = "deadline_exceeded"
+	CodeNotFound           Code = "not_found"
+	CodeAlreadyExists      Code = "already_exists"
+	CodePermissionDenied   Code = "permission_denied"
+	CodeResourceExhausted  Code = "resource_exhausted"
+	CodeFailedPrecondition Code = "failed_precondition"
+	CodeAborted            Code = "aborted"
+	CodeUnimplemented      Code = "unimplemented"
+	CodeInternal           Code = "internal"
+	CodeUnavailable        Code = "unavailable"
+	CodeUnauthenticated    Code = "unauthenticated"
+)
+
+var codeHTTPStatus = map[Code]int{
+	CodeUnknown:            http.StatusInternalServerError,
+	CodeCanceled:           499, // client closed request
+	CodeInvalidArgument:    http.StatusBadRequest,
+	CodeDeadlineExceeded:   http.StatusGatewayTimeout,
+	CodeNotFound:           http.StatusNotFound,
+	CodeAlreadyExists:      http.StatusConflict,
+	CodePermissionDenied:   http.StatusForbidden,
+	CodeResourceExhausted:  http.StatusTooManyRequests,
+	CodeFailedPrecondition: http.StatusPreconditionFailed,
+	CodeAborted:            http.StatusConflict,
+	CodeUnimplemented:      http.StatusNotImplemented,
+	CodeInternal:           http.StatusInternalServerError,
+	CodeUnavailable:        http.StatusServiceUnavailable,
+	CodeUnauthenticated:    http.StatusUnauthorized,
+}
+
+// HTTPStatus returns the HTTP status code corresponding to the error code.
+func (c Code) HTTPStatus() int {
+	if status, ok := codeHTTPStatus[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeForHTTPStatus returns the error code best matching an HTTP status code.
+func CodeForHTTPStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return CodeInvalidArgument
+	case http.StatusUnauthorized:
+		return CodeUnauthenticated
+	case http.StatusForbidden:
+		return CodePermissionDenied
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeAlreadyExists
+	case http.StatusPreconditionFailed:
+		return CodeFailedPrecondition
+	case http.StatusTooManyRequests:
+		return CodeResourceExhausted
+	case http.StatusNotImplemented:
+		return CodeUnimplemented
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeDeadlineExceeded
+	case 499:
+		return CodeCanceled
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeUnknown
+}
+
+type Coder interface {
+	Code() Code
+}
+
+type Hinter interface {
+	Hints() []string
+}
+
+// PublicMessager is implemented by errors with a message that is safe to
+// show clients even when the error is internal.
+type PublicMessager interface {
+	PublicMessage() string
+}
+
+// CodeOf returns the code of the outermost Coder in the error chain, or
+// CodeUnknown if there is none.  Validation errors are CodeInvalidArgument.
+func CodeOf(err error) Code {
+	var coder Coder
+	if stderrors.As(err, &coder) {
+		return coder.Code()
+	}
+	var validationError *ValidationError
+	if stderrors.As(err, &validationError) {
+		return CodeInvalidArgument
+	}
+	return CodeUnknown
+}
+
+// HintsOf returns the hints of every Hinter in the error tree, depth first
+// with the outermost first.
+func HintsOf(err error) []string {
+	var result []string
+	walkTree(err, func(err error) bool {
+		if hinter, ok := err.(Hinter); ok {
+			result = append(result, hinter.Hints()...)
+		}
+		return true
+	})
+	return result
+}
+
+// PublicMessageOf returns the first non-empty public message in the error
+// tree, depth first with the outermost first.
+func PublicMessageOf(err error) (string, bool) {
+	var result string
+	walkTree(err, func(err error) bool {
+		if messager, ok := err.(PublicMessager); ok && messager.PublicMessage() != "" {
+			result = messager.PublicMessage()
+			return false
+		}
+		return true
+	})
+	return result, result != ""
+}
+
+// walkTree calls fn for err and each error it wraps, depth first, until fn
+// returns false.  It returns false if fn did.
+func walkTree(err error, fn func(error) bool) bool {
+	if err == nil {
+		return true
+	}
+	if !fn(err) {
+		return false
+	}
+	switch unwrapper := err.(type) {
+	case Unwrapper:
+		return walkTree(unwrapper.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, cause := range unwrapper.Unwrap() {
+			if !walkTree(cause, fn) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// WithHints returns err with additional user-facing hints.  A CodeError is
+// copied with the hints added, and other errors are wrapped.
+func WithHints(err error, hints ...string) error {
+	if err == nil || len(hints) == 0 {
+		return err
+	}
+	if codeError, ok := err.(CodeError); ok {
+		return codeError.WithHints(hints...)
+	}
+	return &annotatedError{cause: err, hints: hints}
+}
+
+// WithPublicMessage returns err with a message to show clients in place of
+// its own, which may reveal internal details.  A CodeError is copied with
+// the message set, and other errors are wrapped.
+func WithPublicMessage(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	if codeError, ok := err.(CodeError); ok {
+		return codeError.WithPublicMessage(message)
+	}
+	return &annotatedError{cause: err, publicMessage: message}
+}
+
+// annotatedError adds hints or a public message to an error without
+// changing how it is formatted, logged or traced.
+type annotatedError struct {
+	cause         error
+	hints         []string
+	publicMessage string
+}
+
+func (e *annotatedError) Error() string {
+	return e.cause.Error()
+}
+
+func (e *annotatedError) Message() string {
+	return Message(e.cause)
+}
+
+func (e *annotatedError) Unwrap() error {
+	return e.cause
+}
+
+func (e *annotatedError) Hints() []string {
+	return e.hints
+}
+
+func (e *annotatedError) PublicMessage() string {
+	return e.publicMessage
+}
+
+func (e *annotatedError) BackTrace() []byte {
+	return BackTrace(e.cause)
+}
+
+func (e *annotatedError) LogValue() slog.Value {
+	if logValuer, ok := e.cause.(slog.LogValuer); ok {
+		return logValuer.LogValue()
+	}
+	return slog.StringValue(e.cause.Error())
+}
+
+// CodeError is an error with a code and optional user-facing hints.
+type CodeError struct {
+	message       string
+	publicMessage string
+	code          Code
+	hints         []string
+	cause         error
+	stack         Stack
+}
+
+func (e CodeError) BackTrace() []byte {
+	return BackTrace(e)
+}
+
+func (e CodeError) TrimStack(parent Stack) error {
+	trimmedStack, ok := e.stack.Trim(parent)
+	if ok {
+		e.stack = trimmedStack
+	}
+	return e
+}
+
+func (e CodeError) Stack() Stack {
+	return e.stack
+}
+
+func (e CodeError) Error() string {
+	return ErrorString(e.message, e.cause)
+}
+
+func (e CodeError) LogValue() slog.Value {
+	const logKeyCode = "code"
+	const logKeyHints = "hints"
+
+	result := LogValues(e.message, e.cause, e.stack)
+	result[logKeyCode] = string(e.code)
+	if len(e.hints) > 0 {
+		result[logKeyHints] = e.hints
+	}
+	return slog.AnyValue(result)
+}
+
+func (e CodeError) Unwrap() error {
+	return e.cause
+}
+
+func (e CodeError) Message() string {
+	return e.message
+}
+
+func (e CodeError) Code() Code {
+	return e.code
+}
+
+func (e CodeError) Hints() []string {
+	return e.hints
+}
+
+func (e CodeError) PublicMessage() string {
+	return e.publicMessage
+}
+
+// WithPublicMessage returns a copy of the error with a message to show
+// clients in place of its own, which may reveal internal details.
+func (e CodeError) WithPublicMessage(message string) CodeError {
+	e.publicMessage = message
+	return e
+}
+
+// WithHints returns a copy of the error with additional user-facing hints.
+func (e CodeError) WithHints(hints ...string) CodeError {
+	e.hints = append(e.hints[:len(e.hints):len(e.hints)], hints...)
+	return e
+}
+
+func NewCodeError(code Code, cause error, message string, args ...any) error {
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	result := CodeError{
+		message: message,
+		code:    code,
+		cause:   cause,
+		stack:   NewStack(1), // skip NewCodeError
+	}
+	if stacker, ok := cause.(StackTrimmer); ok {
+		result.cause = stacker.TrimStack(result.stack)
+	}
+	return runHooks(HookCreated, result)
+}
@@ -0,0 +1,122 @@
+package errors
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ContextError records why and when a context was found to be done.
+type ContextError struct {
+	err      error
+	cause    error
+	deadline time.Time
+	observed time.Time
+	stack    Stack
+}
+
+// FromContext returns nil if ctx is not done.  Otherwise, it wraps ctx.Err()
+// with the context's cause, its deadline, and the stack at which the
+// cancellation was observed.
+func FromContext(ctx context.Context) error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+
+	result := &ContextError{
+		err:      err,
+		observed: time.Now(),
+		stack:    NewStack(1), // skip FromContext
+	}
+
+	if cause := context.Cause(ctx); cause != nil && cause != err {
+		result.cause = cause
+		if stacker, ok := cause.(StackTrimmer); ok {
+			result.cause = stacker.TrimStack(result.stack)
+		}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		result.deadline = deadline
+	}
+
+	return runHooks(HookCreated, result)
+}
+
+func (e *ContextError) Error() string {
+	return ErrorString(e.err.Error(), e.cause)
+}
+
+func (e *ContextError) Message() string {
+	return e.err.Error()
+}
+
+// Unwrap exposes both ctx.Err() and the context's cause.
+func (e *ContextError) Unwrap() []error {
+	if e.cause == nil {
+		return []error{e.err}
+	}
+	return []error{e.err, e.cause}
+}
+
+// Cause returns the cause passed to the context's cancel function, if any.
+func (e *ContextError) Cause() error {
+	return e.cause
+}
+
+func (e *ContextError) Code() Code {
+	if Is(e.err, context.DeadlineExceeded) {
+		return CodeDeadlineExceeded
+	}
+	return CodeCanceled
+}
+
+// Deadline returns the context deadline, if any.
+func (e *ContextError) Deadline() (time.Time, bool) {
+	return e.deadline, !e.deadline.IsZero()
+}
+
+// Overrun returns how long after the deadline the cancellation was observed.
+// It is negative if the context was canceled before its deadline.
+func (e *ContextError) Overrun() (time.Duration, bool) {
+	if e.deadline.IsZero() {
+		return 0, false
+	}
+	return e.observed.Sub(e.deadline), true
+}
+
+func (e *ContextError) Stack() Stack {
+	return e.stack
+}
+
+func (e *ContextError) TrimStack(parent Stack) error {
+	trimmedStack, ok := e.stack.Trim(parent)
+	if ok {
+		result := *e
+		result.stack = trimmedStack
+		return &result
+	}
+	return e
+}
+
+func (e *ContextError) BackTrace() []byte {
+	return BackTrace(e)
+}
+
+func (e *ContextError) LogValue() slog.Value {
+	const logKeyDeadline = "deadline"
+	const logKeyOverrun = "overrun"
+	const logKeyRemaining = "remaining"
+
+	result := LogValues(e.Message(), e.cause, e.stack)
+	if overrun, ok := e.Overrun(); ok {
+		result[logKeyDeadline] = e.deadline.UTC().Format(time.RFC3339Nano)
+		if overrun >= 0 {
+			result[logKeyOverrun] = overrun.String()
+		} else {
+			result[logKeyRemaining] = (-overrun).String()
+		}
+	}
+	return slog.AnyValue(result)
+}
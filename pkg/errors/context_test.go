@@ -0,0 +1,49 @@
+package errors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var ErrTestShutdown = NewSentinel("shutting down")
+
+func TestFromContext(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	assert.NoError(t, FromContext(ctx))
+
+	cancel(ErrTestShutdown)
+	err := FromContext(ctx)
+
+	assert.Equal(t, "context canceled: shutting down", err.Error())
+	assert.True(t, Is(err, context.Canceled))
+	assert.True(t, Is(err, ErrTestShutdown))
+	assert.Equal(t, CodeCanceled, CodeOf(err))
+
+	logValues := err.(*ContextError).LogValue().Any().(map[string]any)
+	assert.Equal(t, "context canceled", logValues["message"])
+	assert.NotEmpty(t, logValues["stack"])
+	assert.NotContains(t, logValues, "deadline")
+}
+
+func TestFromContext_Deadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+
+	err := FromContext(ctx)
+	assert.True(t, Is(err, context.DeadlineExceeded))
+	assert.Equal(t, CodeDeadlineExceeded, CodeOf(err))
+
+	var contextError *ContextError
+	assert.ErrorAs(t, err, &contextError)
+	overrun, ok := contextError.Overrun()
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, overrun, time.Duration(0))
+
+	logValues := contextError.LogValue().Any().(map[string]any)
+	assert.Contains(t, logValues, "deadline")
+	assert.Contains(t, logValues, "overrun")
+}
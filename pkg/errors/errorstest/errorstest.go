@@ -0,0 +1,148 @@
+// Package errorstest provides test assertions for error chains and stacks,
+// and normalization of back traces for golden-file comparison.
+package errorstest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+// EnvUpdateGolden, when set to a non-empty value, makes Golden rewrite golden files.
+const EnvUpdateGolden = "UPDATE_GOLDEN"
+
+// TestingT is the subset of testing.TB used by the assertions.
+type TestingT interface {
+	Errorf(format string, args ...any)
+	Helper()
+}
+
+// Chain returns err and every error it wraps, depth first.
+func Chain(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	result := []error{err}
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		result = append(result, Chain(e.Unwrap())...)
+	case interface{ Unwrap() []error }:
+		for _, cause := range e.Unwrap() {
+			result = append(result, Chain(cause)...)
+		}
+	}
+	return result
+}
+
+// ChainContains asserts that some error in the chain has the given message.
+func ChainContains(t TestingT, err error, message string, msgAndArgs ...any) bool {
+	t.Helper()
+
+	var messages []string
+	for _, e := range Chain(err) {
+		if errors.Message(e) == message {
+			return true
+		}
+		messages = append(messages, errors.Message(e))
+	}
+
+	return assert.Fail(t, fmt.Sprintf("Error chain does not contain message %q.\n"+
+		"Messages: %q", message, messages), msgAndArgs...)
+}
+
+// StackIncludes asserts that the stack of some error in the chain includes a
+// frame whose function name ends with the given name, such as
+// `errorstest.TestStackIncludes` or `pkg/errors.NewStack`.
+func StackIncludes(t TestingT, err error, function string, msgAndArgs ...any) bool {
+	t.Helper()
+
+	var functions []string
+	for _, e := range Chain(err) {
+		stacker, ok := e.(errors.Stacker)
+		if !ok {
+			continue
+		}
+		for _, frame := range stacker.Stack().Frames() {
+			if strings.HasSuffix(frame.Function(), function) {
+				return true
+			}
+			functions = append(functions, frame.Function())
+		}
+	}
+
+	return assert.Fail(t, fmt.Sprintf("Error stack does not include function %q.\n"+
+		"Functions: %q", function, functions), msgAndArgs...)
+}
+
+// HasCode asserts that the error chain has the given code.
+func HasCode(t TestingT, err error, code errors.Code, msgAndArgs ...any) bool {
+	t.Helper()
+
+	if actual := errors.CodeOf(err); actual != code {
+		return assert.Fail(t, fmt.Sprintf("Error code %q is not %q", actual, code), msgAndArgs...)
+	}
+	return true
+}
+
+// IsSentinel asserts that the error chain matches the sentinel using errors.Is.
+func IsSentinel(t TestingT, err error, sentinel error, msgAndArgs ...any) bool {
+	t.Helper()
+
+	if !errors.Is(err, sentinel) {
+		return assert.Fail(t, fmt.Sprintf("Error chain does not match sentinel %q.\n"+
+			"Error: %q", sentinel, err), msgAndArgs...)
+	}
+	return true
+}
+
+var (
+	regexpAddress  = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	regexpFileLine = regexp.MustCompile(`(?:[A-Za-z]:)?[^\s:]*[/\\]([^/\\\s:]+\.\w+):\d+`)
+	regexpLine     = regexp.MustCompile(`([^/\\\s:]+\.\w+):\d+`)
+	regexpAsmFile  = regexp.MustCompile(`\basm_\w+\.s\b`)
+)
+
+// NormalizeBackTrace removes addresses, directories, line numbers and
+// architecture-specific assembly file names from back trace output, so it may
+// be compared against a golden file.
+func NormalizeBackTrace(backTrace []byte) []byte {
+	result := regexpAddress.ReplaceAll(backTrace, []byte("0x?"))
+	result = regexpFileLine.ReplaceAll(result, []byte("$1"))
+	result = regexpLine.ReplaceAll(result, []byte("$1"))
+	result = regexpAsmFile.ReplaceAll(result, []byte("asm.s"))
+	return result
+}
+
+// Golden asserts that the normalized back trace of err matches the golden
+// file testdata/<name>.golden.  Set UPDATE_GOLDEN=1 to rewrite the file.
+func Golden(t TestingT, err error, name string, msgAndArgs ...any) bool {
+	t.Helper()
+
+	actual := NormalizeBackTrace(errors.BackTrace(err))
+	path := filepath.Join("testdata", name+".golden")
+
+	if os.Getenv(EnvUpdateGolden) != "" {
+		if writeErr := os.WriteFile(path, actual, 0o644); writeErr != nil {
+			return assert.Fail(t, fmt.Sprintf("Failed to update golden file %q: %v", path, writeErr), msgAndArgs...)
+		}
+		return true
+	}
+
+	expected, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return assert.Fail(t, fmt.Sprintf("Failed to read golden file %q: %v", path, readErr), msgAndArgs...)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		return assert.Equal(t, string(expected), string(actual), msgAndArgs...)
+	}
+	return true
+}
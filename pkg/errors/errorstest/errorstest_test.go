@@ -0,0 +1,69 @@
+package errorstest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+var ErrTest = errors.NewSentinel("test sentinel")
+
+// recordingT records the failures reported to it
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingT) Helper() {}
+
+func newTestError() error {
+	return errors.NewCodeError(errors.CodeNotFound, errors.WrapSentinel(ErrTest, "lookup failed"), "widget missing")
+}
+
+func TestAssertions(t *testing.T) {
+	err := newTestError()
+
+	ChainContains(t, err, "lookup failed")
+	ChainContains(t, err, "test sentinel")
+	StackIncludes(t, err, "errorstest.newTestError")
+	StackIncludes(t, err, "errorstest.TestAssertions")
+	HasCode(t, err, errors.CodeNotFound)
+	IsSentinel(t, err, ErrTest)
+
+	recorder := new(recordingT)
+	assert.False(t, ChainContains(recorder, err, "absent"))
+	assert.False(t, StackIncludes(recorder, err, "errorstest.absent"))
+	assert.False(t, HasCode(recorder, err, errors.CodeInternal))
+	assert.False(t, IsSentinel(recorder, err, errors.NewSentinel("test sentinel")))
+
+	if assert.Len(t, recorder.errors, 4) {
+		assert.Contains(t, recorder.errors[0], `does not contain message "absent"`)
+		assert.Contains(t, recorder.errors[1], `does not include function "errorstest.absent"`)
+		assert.Contains(t, recorder.errors[2], `Error code "not_found" is not "internal"`)
+		assert.Contains(t, recorder.errors[3], `does not match sentinel "test sentinel"`)
+	}
+}
+
+func TestNormalizeBackTrace(t *testing.T) {
+	backTrace := []byte("Root Cause: boom\n" +
+		"  main.(*T).run\n" +
+		"    /home/user/src/main.go:42\n" +
+		"  main.main\n" +
+		"    C:\\src\\main.go:7 +0x1d\n")
+
+	assert.Equal(t, "Root Cause: boom\n"+
+		"  main.(*T).run\n"+
+		"    main.go\n"+
+		"  main.main\n"+
+		"    main.go +0x?\n", string(NormalizeBackTrace(backTrace)))
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, newTestError(), "code_error")
+}
@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Process exit codes, following BSD sysexits(3).
+const (
+	ExitOK          = 0
+	ExitFailure     = 1
+	ExitUsage       = 64
+	ExitDataErr     = 65
+	ExitNoInput     = 66
+	ExitNoUser      = 67
+	ExitNoHost      = 68
+	ExitUnavailable = 69
+	ExitSoftware    = 70
+	ExitOSErr       = 71
+	ExitOSFile      = 72
+	ExitCantCreat   = 73
+	ExitIOErr       = 74
+	ExitTempFail    = 75
+	ExitProtocol    = 76
+	ExitNoPerm      = 77
+	ExitConfig      = 78
+)
+
+type SentinelExitCode struct {
+	Sentinel error
+	ExitCode int
+}
+
+// ExitCodeTable maps error chains to process exit codes.  Sentinels are
+// matched first (in order, using Is), then panic kinds, then error codes.
+type ExitCodeTable struct {
+	Sentinels []SentinelExitCode
+	Panics    map[PanicKind]int
+	Codes     map[Code]int
+	Default   int
+}
+
+func (t *ExitCodeTable) ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	for _, sentinel := range t.Sentinels {
+		if Is(err, sentinel.Sentinel) {
+			return sentinel.ExitCode
+		}
+	}
+
+	if kind := PanicKindOf(err); kind != PanicKindNone {
+		if exitCode, ok := t.Panics[kind]; ok {
+			return exitCode
+		}
+	}
+
+	if exitCode, ok := t.Codes[CodeOf(err)]; ok {
+		return exitCode
+	}
+
+	return t.Default
+}
+
+// MapSentinel adds an exit code for errors matching sentinel.
+func (t *ExitCodeTable) MapSentinel(sentinel error, exitCode int) {
+	t.Sentinels = append(t.Sentinels, SentinelExitCode{
+		Sentinel: sentinel,
+		ExitCode: exitCode,
+	})
+}
+
+func NewExitCodeTable() *ExitCodeTable {
+	return &ExitCodeTable{
+		Panics: map[PanicKind]int{
+			PanicKindRuntime: ExitSoftware,
+			PanicKindError:   ExitSoftware,
+			PanicKindValue:   ExitSoftware,
+		},
+		Codes: map[Code]int{
+			CodeInvalidArgument:    ExitDataErr,
+			CodeNotFound:           ExitNoInput,
+			CodeAlreadyExists:      ExitCantCreat,
+			CodePermissionDenied:   ExitNoPerm,
+			CodeUnauthenticated:    ExitNoPerm,
+			CodeResourceExhausted:  ExitTempFail,
+			CodeDeadlineExceeded:   ExitTempFail,
+			CodeAborted:            ExitTempFail,
+			CodeFailedPrecondition: ExitConfig,
+			CodeUnimplemented:      ExitSoftware,
+			CodeInternal:           ExitSoftware,
+			CodeUnavailable:        ExitUnavailable,
+		},
+		Default: ExitFailure,
+	}
+}
+
+var (
+	// ExitCodes is the table used by ExitCode and Exit.
+	ExitCodes = NewExitCodeTable()
+
+	// ExitVerbose enables printing the full back trace in Exit.
+	ExitVerbose bool
+
+	// ExitOutput receives the messages printed by Exit.
+	ExitOutput io.Writer = os.Stderr
+
+	// ExitFunc terminates the process.  It may be replaced for testing.
+	ExitFunc = os.Exit
+)
+
+// ExitCode returns the process exit code for err using ExitCodes.
+func ExitCode(err error) int {
+	return ExitCodes.ExitCode(err)
+}
+
+// Exit prints the user-facing message and hints of err, and terminates the
+// process with its exit code.  A nil error exits successfully.
+func Exit(err error) {
+	if err != nil {
+		writeExitMessage(ExitOutput, err)
+	}
+	ExitFunc(ExitCode(err))
+}
+
+func writeExitMessage(w io.Writer, err error) {
+	_, _ = fmt.Fprintf(w, "Error: %s\n", Message(err))
+	for _, hint := range HintsOf(err) {
+		_, _ = fmt.Fprintf(w, "Hint: %s\n", hint)
+	}
+	if ExitVerbose {
+		_, _ = fmt.Fprintf(w, "\n%s", BackTrace(err))
+	}
+}
@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	table := NewExitCodeTable()
+	table.MapSentinel(ErrTest, ExitUsage)
+
+	var nilMap map[string]int
+	var runtimePanic error
+	func() {
+		defer func() {
+			if e := recover(); e != nil {
+				runtimePanic = NewPanicError(e, -1)
+			}
+		}()
+		nilMap["a"] = 1
+	}()
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "Nil", err: nil, want: ExitOK},
+		{name: "Default", err: newError("plain"), want: ExitFailure},
+		{name: "Sentinel", err: wrapError(ErrTest, "wrapped"), want: ExitUsage},
+		{name: "Code", err: NewCodeError(CodeNotFound, nil, "missing"), want: ExitNoInput},
+		{name: "Validation", err: wrapError(func() error {
+			v := NewValidation()
+			v.Addf("name", "required")
+			return v.Err()
+		}(), "invalid"), want: ExitDataErr},
+		{name: "Panic", err: runtimePanic, want: ExitSoftware},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, table.ExitCode(tt.err))
+		})
+	}
+
+	assert.Equal(t, PanicKindRuntime, PanicKindOf(runtimePanic))
+}
+
+func TestExit(t *testing.T) {
+	output := new(bytes.Buffer)
+	var exitCode int
+	defer func(exitFunc func(int)) { ExitFunc = exitFunc }(ExitFunc)
+	defer func(exitOutput io.Writer) { ExitOutput = exitOutput }(ExitOutput)
+	ExitFunc = func(code int) { exitCode = code }
+	ExitOutput = output
+
+	Exit(WithHints(NewCodeError(CodeUnavailable, newError("connection refused"), "server unavailable"),
+		"retry later"))
+
+	assert.Equal(t, ExitUnavailable, exitCode)
+	assert.Equal(t, "Error: server unavailable\nHint: retry later\n", output.String())
+}
+
+func TestExit_WrappedHints(t *testing.T) {
+	output := new(bytes.Buffer)
+	defer func(exitFunc func(int)) { ExitFunc = exitFunc }(ExitFunc)
+	defer func(exitOutput io.Writer) { ExitOutput = exitOutput }(ExitOutput)
+	ExitFunc = func(int) {}
+	ExitOutput = output
+
+	err := WithHints(newError("disk full"), "free some space")
+	assert.Equal(t, "disk full", err.Error())
+	Exit(err)
+
+	assert.Equal(t, "Error: disk full\nHint: free some space\n", output.String())
+}
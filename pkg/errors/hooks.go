@@ -0,0 +1,174 @@
+package errors
+
+import (
+	"encoding/hex"
+	"expvar"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+type HookKind int
+
+const (
+	// HookCreated is reported when a stack-capturing error is created.
+	HookCreated HookKind = iota
+	// HookPanic is reported when a recovered panic is converted to an error.
+	HookPanic
+)
+
+func (k HookKind) String() string {
+	switch k {
+	case HookCreated:
+		return "created"
+	case HookPanic:
+		return "panic"
+	default:
+		return "unknown"
+	}
+}
+
+type HookEvent struct {
+	Kind HookKind
+	Err  error
+}
+
+// Hook observes error creation.  Hooks are called synchronously by the
+// goroutine creating the error and must be safe for concurrent use.
+type Hook func(event HookEvent)
+
+type hookEntry struct {
+	hook Hook
+}
+
+var (
+	hooks     atomic.Pointer[[]*hookEntry]
+	hooksLock sync.Mutex
+)
+
+// RegisterHook adds a hook to the global registry, returning a function that removes it.
+func RegisterHook(hook Hook) (unregister func()) {
+	entry := &hookEntry{hook: hook}
+
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+
+	var current []*hookEntry
+	if p := hooks.Load(); p != nil {
+		current = *p
+	}
+	next := make([]*hookEntry, len(current), len(current)+1)
+	copy(next, current)
+	next = append(next, entry)
+	hooks.Store(&next)
+
+	return func() {
+		hooksLock.Lock()
+		defer hooksLock.Unlock()
+
+		current := *hooks.Load()
+		next := make([]*hookEntry, 0, len(current))
+		for _, e := range current {
+			if e != entry {
+				next = append(next, e)
+			}
+		}
+		hooks.Store(&next)
+	}
+}
+
+func runHooks(kind HookKind, err error) error {
+	p := hooks.Load()
+	if p == nil {
+		return err
+	}
+
+	event := HookEvent{Kind: kind, Err: err}
+	for _, entry := range *p {
+		entry.hook(event)
+	}
+	return err
+}
+
+// Origin returns the function and line at which the error was created, or
+// "unknown" if the error has no stack.
+func Origin(err error) string {
+	stacker, ok := err.(Stacker)
+	if !ok || stacker.Stack().Len() == 0 {
+		return "unknown"
+	}
+
+	frame := stacker.Stack().Frame(0)
+	_, line := frame.FileLine()
+	return frame.FunctionShort() + ":" + strconv.Itoa(line)
+}
+
+// Fingerprint returns a stable identifier for errors of the same type and
+// code created along the same call path.  Messages are excluded, since they
+// may contain formatted values, and so are line numbers, so that
+// fingerprints survive unrelated edits.
+func Fingerprint(err error) string {
+	hash := fnv.New64a()
+	_, _ = fmt.Fprintf(hash, "%T", err)
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write([]byte(CodeOf(err)))
+
+	if stacker, ok := err.(Stacker); ok {
+		stack := stacker.Stack()
+		for i := 0; i < stack.Len(); i++ {
+			_, _ = hash.Write([]byte{0})
+			_, _ = hash.Write([]byte(stack.Frame(i).Function()))
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Counters counts errors per origin and fingerprint, and publishes the
+// counts through expvar.
+type Counters struct {
+	origins      *expvar.Map
+	fingerprints *expvar.Map
+	panics       *expvar.Map
+}
+
+func (c *Counters) Hook(event HookEvent) {
+	origin := Origin(event.Err)
+	if event.Kind == HookPanic {
+		c.panics.Add(origin, 1)
+	} else {
+		c.origins.Add(origin, 1)
+	}
+	c.fingerprints.Add(Fingerprint(event.Err), 1)
+}
+
+func countersMap(parent *expvar.Map, key string) *expvar.Map {
+	if existing, ok := parent.Get(key).(*expvar.Map); ok {
+		return existing
+	}
+	result := new(expvar.Map)
+	parent.Set(key, result)
+	return result
+}
+
+// NewCounters returns counters published as the named expvar map.  An
+// existing map of the same name is reused.
+func NewCounters(name string) *Counters {
+	parent, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		parent = expvar.NewMap(name)
+	}
+
+	return &Counters{
+		origins:      countersMap(parent, "origins"),
+		fingerprints: countersMap(parent, "fingerprints"),
+		panics:       countersMap(parent, "panics"),
+	}
+}
+
+// PublishCounters registers a Counters hook published as the named expvar map.
+func PublishCounters(name string) (unregister func()) {
+	return RegisterHook(NewCounters(name).Hook)
+}
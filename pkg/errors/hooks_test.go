@@ -0,0 +1,76 @@
+package errors
+
+import (
+	"expvar"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterHook(t *testing.T) {
+	var events []HookEvent
+	unregister := RegisterHook(func(event HookEvent) {
+		events = append(events, event)
+	})
+
+	created := newError("created")
+	func() {
+		defer func() {
+			if e := recover(); e != nil {
+				_ = NewPanicError(e, -1)
+			}
+		}()
+		panic("boom")
+	}()
+
+	unregister()
+	_ = newError("ignored")
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, HookCreated, events[0].Kind)
+	assert.Equal(t, created, events[0].Err)
+	assert.Equal(t, HookPanic, events[1].Kind)
+}
+
+func TestCounters(t *testing.T) {
+	// expvar maps outlive the test, so reset counts from earlier runs
+	if counters, ok := expvar.Get("errors_test").(*expvar.Map); ok {
+		counters.Init()
+	}
+
+	unregister := PublishCounters("errors_test")
+	defer unregister()
+
+	for i := 0; i < 3; i++ {
+		// formatted values share a fingerprint
+		_ = newError(fmt.Sprintf("repeated %d", i))
+	}
+
+	counters := expvar.Get("errors_test").(*expvar.Map)
+	fingerprints := counters.Get("fingerprints").(*expvar.Map)
+	origins := counters.Get("origins").(*expvar.Map)
+
+	var fingerprintCount, originCount int64
+	fingerprints.Do(func(kv expvar.KeyValue) {
+		fingerprintCount += kv.Value.(*expvar.Int).Value()
+		assert.Equal(t, int64(3), kv.Value.(*expvar.Int).Value())
+	})
+	origins.Do(func(kv expvar.KeyValue) {
+		originCount += kv.Value.(*expvar.Int).Value()
+		assert.Contains(t, kv.Key, "TestCounters")
+	})
+
+	assert.Equal(t, int64(3), fingerprintCount)
+	assert.Equal(t, int64(3), originCount)
+}
+
+func TestFingerprint(t *testing.T) {
+	newCodeError := func(code Code, id int) error {
+		return NewCodeError(code, nil, "widget %d not found", id)
+	}
+
+	assert.Equal(t, Fingerprint(newCodeError(CodeNotFound, 1)), Fingerprint(newCodeError(CodeNotFound, 2)))
+	assert.NotEqual(t, Fingerprint(newCodeError(CodeNotFound, 1)), Fingerprint(newCodeError(CodeInternal, 1)))
+	assert.NotEqual(t, Fingerprint(newCodeError(CodeNotFound, 1)), Fingerprint(newError("widget 1 not found")))
+}
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"log/slog"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
@@ -38,13 +39,36 @@ func TestPanicError(t *testing.T) {
 	assert.ErrorAs(t, err, &stacker)
 
 	s := stacker.Stack()
-	assert.Len(t, s, frameCount+callerCount+anonymousCount+panicCount)
-	if len(s) != frameCount+callerCount+anonymousCount+panicCount {
+	assert.Equal(t, frameCount+callerCount+anonymousCount+panicCount, s.Len())
+	if s.Len() != frameCount+callerCount+anonymousCount+panicCount {
 		spew.Dump(s)
 	}
 	spew.Dump(s.LogValue().Any())
 
-	var valueError ValueError
-	assert.ErrorAs(t, err, &valueError)
-	assert.Equal(t, valueError.Value(), 123)
+	var panicErr valueError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, panicErr.Value(), 123)
+}
+
+func TestPanicError_Error(t *testing.T) {
+	cause := newError("boom")
+
+	var err error
+	func() {
+		defer func() {
+			if e := recover(); e != nil {
+				err = NewPanicError(e, -1)
+			}
+		}()
+		panic(cause)
+	}()
+
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, PanicKindError, PanicKindOf(err))
+	assert.Equal(t, PanicKindError, PanicKindOf(wrapError(err, "wrapped")))
+
+	var logValuer slog.LogValuer
+	if assert.ErrorAs(t, err, &logValuer) {
+		assert.NotContains(t, logValuer.LogValue().Any(), "value")
+	}
 }
@@ -0,0 +1,243 @@
+// Package problem renders errors as RFC 7807 `application/problem+json`
+// responses, and decodes such responses back into errors.
+package problem
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+const ContentType = "application/problem+json"
+
+const (
+	HeaderCorrelationID = "X-Correlation-ID"
+	HeaderRequestID     = "X-Request-ID"
+)
+
+// maxBodySize bounds the size of problem documents read by Decode.
+const maxBodySize = 1 << 20
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type          string                `json:"type,omitempty"`
+	Title         string                `json:"title"`
+	Status        int                   `json:"status"`
+	Detail        string                `json:"detail,omitempty"`
+	Instance      string                `json:"instance,omitempty"`
+	Code          errors.Code           `json:"code,omitempty"`
+	Hints         []string              `json:"hints,omitempty"`
+	InvalidParams []errors.InvalidParam `json:"invalid-params,omitempty"`
+	CorrelationID string                `json:"correlation-id,omitempty"`
+}
+
+// New builds a problem from an error.  Only the user-facing message, code,
+// hints and validation parameters are included; causes and stacks never are.
+// Unknown and internal errors are described by their status, unless they
+// provide a public message.
+func New(err error, correlationID string) *Problem {
+	code := errors.CodeOf(err)
+	status := code.HTTPStatus()
+
+	result := &Problem{
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        errors.Message(err),
+		Code:          code,
+		Hints:         errors.HintsOf(err),
+		CorrelationID: correlationID,
+	}
+
+	if result.Title == "" {
+		result.Title = string(code)
+	}
+
+	if code == errors.CodeUnknown || code == errors.CodeInternal {
+		result.Detail = result.Title
+		if message, ok := errors.PublicMessageOf(err); ok {
+			result.Detail = message
+		}
+	}
+
+	var validationError *errors.ValidationError
+	if errors.As(err, &validationError) {
+		result.InvalidParams = validationError.InvalidParams()
+	}
+
+	return result
+}
+
+// Err converts the problem back into an error.  Its correlation ID is kept,
+// and returned by CorrelationIDOf.
+func (p *Problem) Err() error {
+	code := p.Code
+	if code == "" {
+		code = errors.CodeForHTTPStatus(p.Status)
+	}
+
+	message := p.Detail
+	if message == "" {
+		message = p.Title
+	}
+
+	var cause error
+	if len(p.InvalidParams) > 0 {
+		validation := errors.NewValidation()
+		for _, param := range p.InvalidParams {
+			// reasons are messages, not formats
+			validation.Addf(param.Name, "%s", param.Reason)
+		}
+		cause = validation.Err()
+	}
+
+	err := errors.WithHints(errors.NewCodeError(code, cause, message), p.Hints...)
+	if p.CorrelationID != "" {
+		err = &correlatedError{cause: err, correlationID: p.CorrelationID}
+	}
+	return err
+}
+
+// CorrelationIDOf returns the correlation ID of the problem an error was
+// decoded from, if any.
+func CorrelationIDOf(err error) (string, bool) {
+	var correlated *correlatedError
+	if errors.As(err, &correlated) {
+		return correlated.correlationID, true
+	}
+	return "", false
+}
+
+// correlatedError keeps the correlation ID of a decoded problem without
+// changing how its error is formatted or traced.
+type correlatedError struct {
+	cause         error
+	correlationID string
+}
+
+func (e *correlatedError) Error() string {
+	return e.cause.Error()
+}
+
+func (e *correlatedError) Message() string {
+	return errors.Message(e.cause)
+}
+
+func (e *correlatedError) Unwrap() error {
+	return e.cause
+}
+
+func (e *correlatedError) BackTrace() []byte {
+	return errors.BackTrace(e.cause)
+}
+
+func (e *correlatedError) LogValue() slog.Value {
+	const logKeyCorrelationID = "correlation_id"
+
+	if logValuer, ok := e.cause.(slog.LogValuer); ok {
+		if result, ok := logValuer.LogValue().Any().(map[string]any); ok {
+			result[logKeyCorrelationID] = e.correlationID
+			return slog.AnyValue(result)
+		}
+	}
+	return slog.AnyValue(map[string]any{
+		"message":           e.cause.Error(),
+		logKeyCorrelationID: e.correlationID,
+	})
+}
+
+// Write renders err to w as a problem document.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	correlationID := CorrelationID(r)
+
+	p := New(err, correlationID)
+	if r != nil && r.URL != nil {
+		p.Instance = r.URL.Path
+	}
+
+	body, marshalErr := json.Marshal(p)
+	if marshalErr != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set(HeaderCorrelationID, correlationID)
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(body)
+}
+
+// CorrelationID returns the request's correlation identifier, generating one
+// if the client did not send one.
+func CorrelationID(r *http.Request) string {
+	if r != nil {
+		if id := r.Header.Get(HeaderCorrelationID); id != "" {
+			return id
+		}
+		if id := r.Header.Get(HeaderRequestID); id != "" {
+			return id
+		}
+	}
+
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// HandlerFunc is an http.Handler that renders returned errors as problems.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f(w, r); err != nil {
+		Write(w, r, err)
+	}
+}
+
+// Decode returns nil for successful responses, and otherwise converts the
+// response into an error.  The response body is consumed but not closed.
+func Decode(resp *http.Response) error {
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	p, err := DecodeProblem(resp)
+	if err != nil {
+		return err
+	}
+	return p.Err()
+}
+
+// DecodeProblem reads a problem document from an error response.  Responses
+// that are not problem documents are described by their status alone.
+func DecodeProblem(resp *http.Response) (*Problem, error) {
+	p := &Problem{
+		Title:         http.StatusText(resp.StatusCode),
+		Status:        resp.StatusCode,
+		CorrelationID: resp.Header.Get(HeaderCorrelationID),
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != ContentType {
+		return p, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, errors.WrapSentinel(err, "failed to read problem response")
+	}
+
+	if err = json.Unmarshal(body, p); err != nil {
+		return nil, errors.WrapSentinel(err, "failed to decode problem response")
+	}
+
+	if p.Status == 0 {
+		p.Status = resp.StatusCode
+	}
+
+	return p, nil
+}
@@ -0,0 +1,119 @@
+package problem
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+func TestRoundTrip(t *testing.T) {
+	handler := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		validation := errors.NewValidation()
+		validation.Field("spec").Addf("name", "required")
+		return errors.WithHints(errors.NewCodeError(errors.CodeInvalidArgument, validation.Err(), "invalid widget"),
+			"check the widget spec")
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/widgets", nil)
+	req.Header.Set(HeaderRequestID, "abc123")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+	assert.Equal(t, "abc123", resp.Header.Get(HeaderCorrelationID))
+
+	p, err := DecodeProblem(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, &Problem{
+		Title:         "Bad Request",
+		Status:        http.StatusBadRequest,
+		Detail:        "invalid widget",
+		Instance:      "/widgets",
+		Code:          errors.CodeInvalidArgument,
+		Hints:         []string{"check the widget spec"},
+		InvalidParams: []errors.InvalidParam{{Name: "spec.name", Reason: "required"}},
+		CorrelationID: "abc123",
+	}, p)
+
+	decoded := p.Err()
+	assert.Equal(t, errors.CodeInvalidArgument, errors.CodeOf(decoded))
+	assert.Equal(t, "invalid widget", errors.Message(decoded))
+	assert.Equal(t, []string{"check the widget spec"}, errors.HintsOf(decoded))
+	correlationID, _ := CorrelationIDOf(decoded)
+	assert.Equal(t, "abc123", correlationID)
+	assert.Equal(t, "abc123", decoded.(slog.LogValuer).LogValue().Any().(map[string]any)["correlation_id"])
+
+	var validationError *errors.ValidationError
+	assert.ErrorAs(t, decoded, &validationError)
+	assert.Equal(t, p.InvalidParams, validationError.InvalidParams())
+}
+
+func TestWrite_NoStack(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	Write(recorder, httptest.NewRequest(http.MethodGet, "/", nil), errors.WrapSentinel(
+		errors.NewSentinel("connection refused"), "lookup failed"))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, "Internal Server Error", body["detail"])
+	assert.NotContains(t, recorder.Body.String(), "lookup failed")
+	assert.NotContains(t, recorder.Body.String(), "connection refused")
+	assert.NotContains(t, recorder.Body.String(), "stack")
+	assert.NotEmpty(t, body["correlation-id"])
+}
+
+func TestWrite_PublicMessage(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	Write(recorder, httptest.NewRequest(http.MethodGet, "/", nil), errors.WrapSentinel(
+		errors.WithPublicMessage(errors.NewCodeError(errors.CodeInternal, nil, "dial 10.0.0.1:5432 failed"),
+			"database unavailable"),
+		"lookup failed"))
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, "database unavailable", body["detail"])
+	assert.NotContains(t, recorder.Body.String(), "10.0.0.1")
+}
+
+func TestWrite_Joined(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	Write(recorder, httptest.NewRequest(http.MethodGet, "/", nil), errors.Join(
+		errors.NewSentinel("connection refused"),
+		errors.WithHints(errors.WithPublicMessage(
+			errors.NewCodeError(errors.CodeInternal, nil, "dial 10.0.0.1:5432 failed"),
+			"database unavailable"), "retry later")))
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, "database unavailable", body["detail"])
+	assert.Equal(t, []any{"retry later"}, body["hints"])
+}
+
+func TestErr_InvalidParams(t *testing.T) {
+	p := &Problem{
+		Status: http.StatusBadRequest,
+		InvalidParams: []errors.InvalidParam{
+			{Name: "ratio", Reason: "must be at most 100%"},
+			{Name: "ratio", Reason: "must be a %d"},
+		},
+	}
+
+	var validationError *errors.ValidationError
+	assert.ErrorAs(t, p.Err(), &validationError)
+	assert.Equal(t, p.InvalidParams, validationError.InvalidParams())
+}
@@ -8,78 +8,51 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/samber/lo"
 )
 
-const framePageSize = 16
-
-type callersPage struct {
-	skip   int
-	frames []uintptr
-	off    int
-}
-
-// nextPage returns the next page of caller frames
-func (c callersPage) nextPage() callersPage {
-	// skip Next() and nextPage() calls
-	const skip = 2
-	page := newCallersPage(c.skip + len(c.frames) + skip)
-	page.skip -= skip
-	return page
-}
-
-// Next returns the next available frame, and an updated cursor, and a success indicator.
-func (c callersPage) Next() (uintptr, callersPage, bool) {
-	switch len(c.frames) {
-	case 0:
-		return uintptr(0), callersPage{}, false
-	case c.off:
-		return c.nextPage().Next()
-	default:
-		frame := c.frames[c.off]
-		c.off++
-		return frame, c, true
-	}
-}
-
-func newCallersPage(skip int) callersPage {
-	frames := make([]uintptr, framePageSize)
-	count := runtime.Callers(skip+2, frames) // skip this function and runtime.Callers
-	if count == 0 {
-		return callersPage{}
-	}
-	return callersPage{
-		skip:   skip,
-		frames: frames[:count],
-	}
-}
-
-type callers struct {
-	scanned []Frame
-	page    callersPage
-}
+const (
+	// stackBufferSize is the initial capacity of pooled program counter buffers.
+	stackBufferSize = 64
+	// maxStackBufferSize bounds the capacity of buffers returned to the pool.
+	maxStackBufferSize = 1024
+)
 
-func (c callers) Next() (uintptr, callers, bool) {
-	frame, page, ok := c.page.Next()
-	if ok {
-		if len(c.scanned)%framePageSize == 0 {
-			c.scanned = slices.Grow(c.scanned, framePageSize)
+var stackBufferPool = sync.Pool{
+	New: func() any {
+		b := make([]uintptr, stackBufferSize)
+		return &b
+	},
+}
+
+// callers captures the program counters of the calling goroutine's stack
+// with a single runtime.Callers call into a pooled buffer, growing the buffer
+// only for deep stacks.  The result is the only allocation.
+func callers(skip int) Stack {
+	bufp := stackBufferPool.Get().(*[]uintptr)
+	defer func() {
+		if cap(*bufp) <= maxStackBufferSize {
+			stackBufferPool.Put(bufp)
 		}
-		c.scanned = append(c.scanned, Frame(frame))
-		c.page = page
-		return frame, c, true
-	}
-	return uintptr(0), c, false
-}
-
-func (c callers) Frames() []Frame {
-	return c.scanned
-}
+	}()
 
-func newCallers(skip int) callers {
-	return callers{
-		page: newCallersPage(skip + 1),
+	for {
+		buf := *bufp
+		count := runtime.Callers(skip+2, buf) // skip this function and runtime.Callers
+		if count == 0 {
+			return Stack{}
+		}
+		if count < len(buf) {
+			frames := make([]Frame, count)
+			for i, pc := range buf[:count] {
+				frames[i] = Frame(pc)
+			}
+			return Stack{frames: frames}
+		}
+		// the stack may have been truncated; retry with a larger buffer
+		*bufp = make([]uintptr, len(buf)*2)
 	}
 }
 
@@ -130,11 +103,30 @@ type StackTrimmer interface {
 	TrimStack(parent Stack) error
 }
 
-type Stack []Frame
+// Stack is an immutable call stack, innermost frame first.  The zero value
+// is an empty stack.
+type Stack struct {
+	frames []Frame
+}
+
+// Len returns the number of frames in the stack.
+func (s Stack) Len() int {
+	return len(s.frames)
+}
+
+// Frame returns the frame at index i, where 0 is the innermost.
+func (s Stack) Frame(i int) Frame {
+	return s.frames[i]
+}
+
+// Frames returns a copy of the frames in the stack.
+func (s Stack) Frames() []Frame {
+	return slices.Clone(s.frames)
+}
 
 func (s Stack) Trim(parent Stack) (Stack, bool) {
-	count := len(s)
-	otherCount := len(parent)
+	count := len(s.frames)
+	otherCount := len(parent.frames)
 
 	if count < otherCount {
 		return s, false
@@ -146,28 +138,23 @@ func (s Stack) Trim(parent Stack) (Stack, bool) {
 
 	// Trim matching stack traces
 	idx := 1
-	for s[count-idx-1].Equals(parent[otherCount-idx-1]) && otherCount > idx+1 {
+	for s.frames[count-idx-1].Equals(parent.frames[otherCount-idx-1]) && otherCount > idx+1 {
 		idx++
 	}
 
-	return s[:count-idx], idx > 0
+	return Stack{frames: s.frames[: count-idx : count-idx]}, idx > 0
 }
 
 func (s Stack) LogValue() slog.Value {
-	return slog.AnyValue(lo.Map(s, func(item Frame, _ int) string {
+	return slog.AnyValue(lo.Map(s.frames, func(item Frame, _ int) string {
 		return item.LogValue().String()
 	}))
 }
 
+// NewStack captures the calling goroutine's stack, skipping the given number
+// of callers.
 func NewStack(skip int) Stack {
-	var ok bool
-
-	c := newCallers(skip + 1) // skip NewStack()
-	_, c, ok = c.Next()
-	for ok {
-		_, c, ok = c.Next()
-	}
-	return c.Frames()
+	return callers(skip + 1) // skip NewStack()
 }
 
 type BackTracer interface {
@@ -233,7 +220,7 @@ func newSimple(message string, cause error) error {
 	if stacker, ok := cause.(StackTrimmer); ok {
 		result.cause = stacker.TrimStack(result.stack)
 	}
-	return result
+	return runHooks(HookCreated, result)
 }
 
 func WrapSentinel(cause error, message string) error {
@@ -258,41 +245,41 @@ type Unwrapper interface {
 func BackTrace(err error) []byte {
 	buffer := new(bytes.Buffer)
 
-	unwrapper, ok := err.(Unwrapper)
-	var cause error
-	if ok {
-		cause = unwrapper.Unwrap()
+	var causes []error
+	switch unwrapper := err.(type) {
+	case Unwrapper:
+		causes = []error{unwrapper.Unwrap()}
+	case interface{ Unwrap() []error }:
+		causes = unwrapper.Unwrap()
 	}
 
-	if cause != nil {
-		if backTracer, ok := cause.(BackTracer); ok {
-			buffer.Write(backTracer.BackTrace())
-		} else {
-			causeMessages := Messages(cause)
-			slices.Reverse(causeMessages)
-			buffer.WriteString("Root Cause: ")
-			buffer.WriteString(causeMessages[0])
-			buffer.WriteString("\n")
-			for i, causeMessage := range causeMessages {
-				if i == 0 {
-					continue
-				}
-				buffer.WriteString("Caused: ")
-				buffer.WriteString(causeMessage)
-				buffer.WriteString("\n")
-			}
+	message := Message(err)
+	caused := false
+	for _, cause := range causes {
+		if cause == nil {
+			continue
 		}
+		if _, ok := cause.(BackTracer); !ok && cause.Error() == message {
+			// restated by the error, such as ctx.Err() by a ContextError
+			continue
+		}
+		writeCauseBackTrace(buffer, cause)
+		caused = true
+	}
 
+	if caused {
 		buffer.WriteString("Caused: ")
 	} else {
 		buffer.WriteString("Root Cause: ")
 	}
 
-	buffer.WriteString(Message(err))
+	buffer.WriteString(message)
 	buffer.WriteString("\n")
 
 	if stacker, ok := err.(Stacker); ok {
-		for _, frame := range stacker.Stack() {
+		stack := stacker.Stack()
+		for i := 0; i < stack.Len(); i++ {
+			frame := stack.Frame(i)
 			buffer.WriteString("  ")
 			buffer.WriteString(frame.Function())
 			buffer.WriteString("\n")
@@ -309,6 +296,29 @@ func BackTrace(err error) []byte {
 	return buffer.Bytes()
 }
 
+// writeCauseBackTrace writes the back trace of a cause, or its messages if
+// it has no back trace
+func writeCauseBackTrace(buffer *bytes.Buffer, cause error) {
+	if backTracer, ok := cause.(BackTracer); ok {
+		buffer.Write(backTracer.BackTrace())
+		return
+	}
+
+	causeMessages := Messages(cause)
+	slices.Reverse(causeMessages)
+	buffer.WriteString("Root Cause: ")
+	buffer.WriteString(causeMessages[0])
+	buffer.WriteString("\n")
+	for i, causeMessage := range causeMessages {
+		if i == 0 {
+			continue
+		}
+		buffer.WriteString("Caused: ")
+		buffer.WriteString(causeMessage)
+		buffer.WriteString("\n")
+	}
+}
+
 func ErrorString(message string, cause error) string {
 	if cause == nil {
 		return message
@@ -333,7 +343,7 @@ func LogValues(message string, cause error, stack Stack) map[string]any {
 		}
 	}
 
-	if len(stack) > 0 {
+	if stack.Len() > 0 {
 		result[logKeyStack] = stack.LogValue().Any()
 	}
 
@@ -0,0 +1,155 @@
+package errors
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// The previous paged stack capture, retained for benchmark comparison.
+
+const legacyFramePageSize = 16
+
+type legacyCallersPage struct {
+	skip   int
+	frames []uintptr
+	off    int
+}
+
+// nextPage returns the next page of caller frames
+func (c legacyCallersPage) nextPage() legacyCallersPage {
+	// skip Next() and nextPage() calls
+	const skip = 2
+	page := newLegacyCallersPage(c.skip + len(c.frames) + skip)
+	page.skip -= skip
+	return page
+}
+
+// Next returns the next available frame, and an updated cursor, and a success indicator.
+func (c legacyCallersPage) Next() (uintptr, legacyCallersPage, bool) {
+	switch len(c.frames) {
+	case 0:
+		return uintptr(0), legacyCallersPage{}, false
+	case c.off:
+		return c.nextPage().Next()
+	default:
+		frame := c.frames[c.off]
+		c.off++
+		return frame, c, true
+	}
+}
+
+func newLegacyCallersPage(skip int) legacyCallersPage {
+	frames := make([]uintptr, legacyFramePageSize)
+	count := runtime.Callers(skip+2, frames) // skip this function and runtime.Callers
+	if count == 0 {
+		return legacyCallersPage{}
+	}
+	return legacyCallersPage{
+		skip:   skip,
+		frames: frames[:count],
+	}
+}
+
+type legacyCallers struct {
+	scanned []Frame
+	page    legacyCallersPage
+}
+
+func (c legacyCallers) Next() (uintptr, legacyCallers, bool) {
+	frame, page, ok := c.page.Next()
+	if ok {
+		if len(c.scanned)%legacyFramePageSize == 0 {
+			c.scanned = slices.Grow(c.scanned, legacyFramePageSize)
+		}
+		c.scanned = append(c.scanned, Frame(frame))
+		c.page = page
+		return frame, c, true
+	}
+	return uintptr(0), c, false
+}
+
+func (c legacyCallers) Frames() []Frame {
+	return c.scanned
+}
+
+func newLegacyCallers(skip int) legacyCallers {
+	return legacyCallers{
+		page: newLegacyCallersPage(skip + 1),
+	}
+}
+
+func legacyNewStack(skip int) Stack {
+	var ok bool
+
+	c := newLegacyCallers(skip + 1) // skip legacyNewStack()
+	_, c, ok = c.Next()
+	for ok {
+		_, c, ok = c.Next()
+	}
+	return Stack{frames: c.Frames()}
+}
+
+func legacyWrapError(err error, message string) error {
+	result := &simple{
+		message: message,
+		cause:   err,
+		stack:   legacyNewStack(1),
+	}
+	if stacker, ok := err.(StackTrimmer); ok {
+		result.cause = stacker.TrimStack(result.stack)
+	}
+	return result
+}
+
+func benchmarkDepths(b *testing.B, fn func(b *testing.B)) {
+	for _, depth := range []struct {
+		name   string
+		frames int
+	}{
+		{name: "Shallow", frames: 4},
+		{name: "Deep", frames: 48},
+	} {
+		b.Run(depth.name, func(b *testing.B) {
+			recursive(depth.frames, func() {
+				fn(b)
+			})
+		})
+	}
+}
+
+func BenchmarkNewStack(b *testing.B) {
+	benchmarkDepths(b, func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = NewStack(0)
+		}
+	})
+}
+
+func BenchmarkNewStack_Legacy(b *testing.B) {
+	benchmarkDepths(b, func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = legacyNewStack(0)
+		}
+	})
+}
+
+func BenchmarkWrap(b *testing.B) {
+	benchmarkDepths(b, func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = wrapError(ErrTest, "wrapped")
+		}
+	})
+}
+
+func BenchmarkWrap_Legacy(b *testing.B) {
+	benchmarkDepths(b, func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = legacyWrapError(ErrTest, "wrapped")
+		}
+	})
+}
@@ -28,43 +28,30 @@ func recursive(count int, fn func()) {
 	}
 }
 
-func TestCallersPage(t *testing.T) {
+func TestNewStack(t *testing.T) {
 	const frames = 24
 	const callers = 3
 	recursive(frames, func() {
-		page := newCallersPage(1) // skip this function
-
-		var frame uintptr
-		var ok bool
-		var count int
-		frame, page, ok = page.Next()
-		for ok {
-			count++
-			frame, page, ok = page.Next()
-		}
-
-		assert.Zero(t, frame)
-		assert.Equal(t, frames+callers, count)
+		s := NewStack(1) // skip this function
+
+		assert.Equal(t, frames+callers, s.Len())
+		assert.Equal(t, s.Len(), cap(s.frames))
+		assert.Equal(t, "code.internetisalie.net/slogan/pkg/errors.recursive", s.Frame(0).Function())
+
+		// copies leave the stack unchanged
+		frames := s.Frames()
+		frames[0] = 0
+		assert.Equal(t, "code.internetisalie.net/slogan/pkg/errors.recursive", s.Frame(0).Function())
 	})
 }
 
-func TestCallers(t *testing.T) {
-	const frameCount = 24
-	const callerCount = 3
-	recursive(frameCount, func() {
-		c := newCallers(1) // skip this function
-
-		var frame uintptr
-		var ok bool
-		var count int
-		frame, c, ok = c.Next()
-		for ok {
-			count++
-			frame, c, ok = c.Next()
-		}
-
-		assert.Zero(t, frame)
-		assert.Equal(t, count, frameCount+callerCount)
+func TestNewStack_Deep(t *testing.T) {
+	const frames = stackBufferSize*3 + 5
+	const callers = 3
+	recursive(frames, func() {
+		s := NewStack(1) // skip this function
+
+		assert.Equal(t, frames+callers, s.Len())
 	})
 }
 
@@ -83,8 +70,8 @@ func TestStackTrimmer(t *testing.T) {
 
 	var outerStacker Stacker
 	assert.ErrorAs(t, err, &outerStacker)
-	assert.Len(t, outerStacker.Stack(), frameCount+callerCount+1) // add inner anonymous function
-	if len(outerStacker.Stack()) != frameCount+callerCount+1 {
+	assert.Equal(t, frameCount+callerCount+1, outerStacker.Stack().Len()) // add inner anonymous function
+	if outerStacker.Stack().Len() != frameCount+callerCount+1 {
 		spew.Dump(outerStacker.Stack())
 	}
 
@@ -96,8 +83,8 @@ func TestStackTrimmer(t *testing.T) {
 
 	var innerStacker Stacker
 	assert.ErrorAs(t, innerError, &innerStacker)
-	assert.Len(t, innerStacker.Stack(), frameCount+1+1) // add inner function and outer statement
-	if len(innerStacker.Stack()) != frameCount+1+1 {
+	assert.Equal(t, frameCount+1+1, innerStacker.Stack().Len()) // add inner function and outer statement
+	if innerStacker.Stack().Len() != frameCount+1+1 {
 		spew.Dump(innerStacker.Stack())
 	}
 }
@@ -115,7 +102,7 @@ func TestStackTrimmer_Global(t *testing.T) {
 
 	var outerStacker Stacker
 	assert.ErrorAs(t, err, &outerStacker)
-	assert.Len(t, outerStacker.Stack(), frameCount+callerCount+1) // add inner anonymous function
+	assert.Equal(t, frameCount+callerCount+1, outerStacker.Stack().Len()) // add inner anonymous function
 
 	var outerUnwrapper Unwrapper
 	assert.ErrorAs(t, err, &outerUnwrapper)
@@ -125,8 +112,8 @@ func TestStackTrimmer_Global(t *testing.T) {
 
 	var innerStacker Stacker
 	assert.ErrorAs(t, innerError, &innerStacker)
-	assert.Len(t, innerStacker.Stack(), 0)
-	if len(innerStacker.Stack()) != 0 {
+	assert.Zero(t, innerStacker.Stack().Len())
+	if innerStacker.Stack().Len() != 0 {
 		spew.Dump(innerStacker.Stack())
 	}
 }
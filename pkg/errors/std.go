@@ -0,0 +1,20 @@
+package errors
+
+import (
+	stderrors "errors"
+)
+
+// Is reports whether any error in err's tree matches target.  See errors.Is.
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As finds the first error in err's tree that matches target.  See errors.As.
+func As(err error, target any) bool {
+	return stderrors.As(err, target)
+}
+
+// Join returns an error wrapping the non-nil errs, or nil if there are none.  See errors.Join.
+func Join(errs ...error) error {
+	return stderrors.Join(errs...)
+}
@@ -0,0 +1,231 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+const validationMessage = "validation failed"
+
+// FieldError is a single validation failure at a field path such as `spec.containers[2].image`.
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (f FieldError) Error() string {
+	if f.Path == "" {
+		return f.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", f.Path, f.Err.Error())
+}
+
+func (f FieldError) Unwrap() error {
+	return f.Err
+}
+
+// InvalidParam is a single entry of the RFC 7807 `invalid-params` extension member.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError aggregates every field error found while validating a value.
+type ValidationError struct {
+	message string
+	fields  []FieldError
+	stack   Stack
+}
+
+func (e *ValidationError) Error() string {
+	buffer := new(strings.Builder)
+	buffer.WriteString(e.message)
+	buffer.WriteString(":")
+	for _, field := range e.fields {
+		buffer.WriteString("\n  - ")
+		buffer.WriteString(field.Error())
+	}
+	return buffer.String()
+}
+
+func (e *ValidationError) Message() string {
+	return e.message
+}
+
+// Unwrap exposes every field error, so Is and As match causes of any field.
+func (e *ValidationError) Unwrap() []error {
+	result := make([]error, len(e.fields))
+	for i, field := range e.fields {
+		result[i] = field
+	}
+	return result
+}
+
+func (e *ValidationError) Fields() []FieldError {
+	return e.fields
+}
+
+func (e *ValidationError) Stack() Stack {
+	return e.stack
+}
+
+func (e *ValidationError) TrimStack(parent Stack) error {
+	trimmedStack, ok := e.stack.Trim(parent)
+	if ok {
+		return &ValidationError{
+			message: e.message,
+			fields:  e.fields,
+			stack:   trimmedStack,
+		}
+	}
+	return e
+}
+
+func (e *ValidationError) BackTrace() []byte {
+	return BackTrace(e)
+}
+
+// InvalidParams renders the field errors as an RFC 7807 `invalid-params` array.
+func (e *ValidationError) InvalidParams() []InvalidParam {
+	result := make([]InvalidParam, len(e.fields))
+	for i, field := range e.fields {
+		result[i] = InvalidParam{
+			Name:   field.Path,
+			Reason: field.Err.Error(),
+		}
+	}
+	return result
+}
+
+func (e *ValidationError) LogValue() slog.Value {
+	const (
+		logKeyFields     = "fields"
+		logKeyFieldPath  = "path"
+		logKeyFieldError = "error"
+	)
+
+	// a list rather than a map keeps every error of a repeated path
+	fields := make([]any, len(e.fields))
+	for i, field := range e.fields {
+		fields[i] = map[string]any{
+			logKeyFieldPath:  field.Path,
+			logKeyFieldError: field.Err.Error(),
+		}
+	}
+
+	result := LogValues(e.message, nil, e.stack)
+	result[logKeyFields] = fields
+	return slog.AnyValue(result)
+}
+
+type validationErrors struct {
+	fields []FieldError
+}
+
+// Validation collects field errors keyed by path.  Nested collectors created
+// with Field and Index share storage with their parent and prefix their paths.
+type Validation struct {
+	prefix string
+	errors *validationErrors
+}
+
+func NewValidation() *Validation {
+	return &Validation{
+		errors: new(validationErrors),
+	}
+}
+
+// Field returns a nested collector for the named child field.
+func (v *Validation) Field(name string) *Validation {
+	return &Validation{
+		prefix: joinFieldPath(v.prefix, name),
+		errors: v.errors,
+	}
+}
+
+// Index returns a nested collector for the indexed child element.
+func (v *Validation) Index(index int) *Validation {
+	return &Validation{
+		prefix: v.prefix + "[" + strconv.Itoa(index) + "]",
+		errors: v.errors,
+	}
+}
+
+// Add records err against the path relative to this collector.  Nested
+// validation errors are flattened with their paths prefixed.
+func (v *Validation) Add(path string, err error) {
+	if err == nil {
+		return
+	}
+
+	path = joinFieldPath(v.prefix, path)
+
+	var validationError *ValidationError
+	if stderrors.As(err, &validationError) {
+		for _, field := range validationError.fields {
+			v.errors.fields = append(v.errors.fields, FieldError{
+				Path: joinFieldPath(path, field.Path),
+				Err:  field.Err,
+			})
+		}
+		return
+	}
+
+	v.errors.fields = append(v.errors.fields, FieldError{
+		Path: path,
+		Err:  err,
+	})
+}
+
+// Addf records a new error message against the path relative to this collector.
+func (v *Validation) Addf(path string, message string, args ...any) {
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	v.Add(path, stderrors.New(message))
+}
+
+// Check records a new error message against the path when ok is false.
+func (v *Validation) Check(ok bool, path string, message string, args ...any) {
+	if !ok {
+		v.Addf(path, message, args...)
+	}
+}
+
+// Len returns the number of field errors collected across all nested collectors.
+func (v *Validation) Len() int {
+	return len(v.errors.fields)
+}
+
+// Err returns a single ValidationError containing every collected field
+// error, or nil if none were collected.
+func (v *Validation) Err() error {
+	if len(v.errors.fields) == 0 {
+		return nil
+	}
+
+	fields := make([]FieldError, len(v.errors.fields))
+	copy(fields, v.errors.fields)
+
+	return runHooks(HookCreated, &ValidationError{
+		message: validationMessage,
+		fields:  fields,
+		stack:   NewStack(1), // skip Err
+	})
+}
+
+func joinFieldPath(prefix, path string) string {
+	switch {
+	case prefix == "":
+		return path
+	case path == "":
+		return prefix
+	case strings.HasPrefix(path, "["):
+		return prefix + path
+	default:
+		return prefix + "." + path
+	}
+}
@@ -0,0 +1,75 @@
+package errors
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var ErrTestRequired = NewSentinel("required")
+
+func validateContainer(v *Validation, image string) {
+	v.Check(image != "", "image", "must not be empty")
+}
+
+func TestValidation(t *testing.T) {
+	v := NewValidation()
+	assert.NoError(t, v.Err())
+
+	spec := v.Field("spec")
+	spec.Add("name", ErrTestRequired)
+	containers := spec.Field("containers")
+	for i, image := range []string{"a", "b", ""} {
+		validateContainer(containers.Index(i), image)
+	}
+
+	nested := NewValidation()
+	nested.Addf("port", "must be less than %d", 65536)
+	spec.Add("service", nested.Err())
+
+	err := v.Err()
+	assert.Error(t, err)
+	assert.Equal(t, 3, v.Len())
+	assert.Equal(t, "validation failed:\n"+
+		"  - spec.name: required\n"+
+		"  - spec.containers[2].image: must not be empty\n"+
+		"  - spec.service.port: must be less than 65536", err.Error())
+	assert.Equal(t, "validation failed", Message(err))
+	assert.True(t, stderrors.Is(err, ErrTestRequired))
+
+	var validationError *ValidationError
+	assert.ErrorAs(t, err, &validationError)
+	assert.Equal(t, []InvalidParam{
+		{Name: "spec.name", Reason: "required"},
+		{Name: "spec.containers[2].image", Reason: "must not be empty"},
+		{Name: "spec.service.port", Reason: "must be less than 65536"},
+	}, validationError.InvalidParams())
+
+	logValues := validationError.LogValue().Any().(map[string]any)
+	assert.Equal(t, []any{
+		map[string]any{"path": "spec.name", "error": "required"},
+		map[string]any{"path": "spec.containers[2].image", "error": "must not be empty"},
+		map[string]any{"path": "spec.service.port", "error": "must be less than 65536"},
+	}, logValues["fields"])
+	assert.NotEmpty(t, logValues["stack"])
+}
+
+func TestValidation_RepeatedPath(t *testing.T) {
+	v := NewValidation()
+	v.Add("name", ErrTestRequired)
+	v.Addf("name", "must match %q", "[a-z]+")
+
+	var validationError *ValidationError
+	assert.ErrorAs(t, v.Err(), &validationError)
+	assert.Equal(t, []InvalidParam{
+		{Name: "name", Reason: "required"},
+		{Name: "name", Reason: `must match "[a-z]+"`},
+	}, validationError.InvalidParams())
+
+	logValues := validationError.LogValue().Any().(map[string]any)
+	assert.Equal(t, []any{
+		map[string]any{"path": "name", "error": "required"},
+		map[string]any{"path": "name", "error": `must match "[a-z]+"`},
+	}, logValues["fields"])
+}
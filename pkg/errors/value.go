@@ -0,0 +1,148 @@
+package errors
+
+import (
+	"log/slog"
+	"runtime"
+)
+
+// PanicKind classifies the value passed to panic.
+type PanicKind string
+
+const (
+	PanicKindNone    PanicKind = ""
+	PanicKindRuntime PanicKind = "runtime"
+	PanicKindError   PanicKind = "error"
+	PanicKindValue   PanicKind = "value"
+)
+
+// valueError is a recovered panic, carrying the value passed to panic
+type valueError struct {
+	message string
+	value   any
+	cause   error
+	stack   Stack
+	panic   PanicKind
+}
+
+func (e valueError) BackTrace() []byte {
+	return BackTrace(e)
+}
+
+func (e valueError) TrimStack(parent Stack) error {
+	trimmedStack, ok := e.stack.Trim(parent)
+	if ok {
+		return &valueError{
+			message: e.message,
+			cause:   e.cause,
+			value:   e.value,
+			stack:   trimmedStack,
+			panic:   e.panic,
+		}
+	}
+	return e
+}
+
+func (e valueError) Stack() Stack {
+	return e.stack
+}
+
+func (e valueError) Error() string {
+	return ErrorString(e.message, e.cause)
+}
+
+func (e valueError) LogValue() slog.Value {
+	result := LogValues(e.message, e.cause, e.stack)
+	result["value"] = e.value
+	return slog.AnyValue(result)
+}
+
+func (e valueError) Unwrap() error {
+	return e.cause
+}
+
+func (e valueError) Message() string {
+	return e.message
+}
+
+func (e valueError) Value() any {
+	return e.value
+}
+
+// PanicKind returns the kind of recovered panic, or PanicKindNone if the
+// error was not created by NewPanicError.
+func (e valueError) PanicKind() PanicKind {
+	return e.panic
+}
+
+// panicError is a recovered panic carrying an error, which it wraps
+type panicError struct {
+	simple
+	panic PanicKind
+}
+
+func (e *panicError) TrimStack(parent Stack) error {
+	trimmedStack, ok := e.stack.Trim(parent)
+	if ok {
+		return &panicError{
+			simple: simple{
+				message: e.message,
+				cause:   e.cause,
+				stack:   trimmedStack,
+			},
+			panic: e.panic,
+		}
+	}
+	return e
+}
+
+func (e *panicError) PanicKind() PanicKind {
+	return e.panic
+}
+
+func NewPanicError(v any, skipStack int) error {
+	// skip NewPanicError, (parents), runtime.gopanic, runtime.panicmem, runtime.sigpanic
+	stack := NewStack(1 + skipStack + 3)
+
+	if e, ok := v.(error); ok {
+		result := &panicError{
+			simple: simple{
+				message: "panic",
+				cause:   e,
+				stack:   stack,
+			},
+			panic: PanicKindError,
+		}
+		if _, ok := e.(runtime.Error); ok {
+			result.panic = PanicKindRuntime
+		}
+		if stacker, ok := e.(StackTrimmer); ok {
+			result.cause = stacker.TrimStack(result.stack)
+		}
+		return runHooks(HookPanic, result)
+	}
+
+	return runHooks(HookPanic, valueError{
+		message: "panic",
+		value:   v,
+		stack:   stack,
+		panic:   PanicKindValue,
+	})
+}
+
+// PanicKindOf returns the kind of the first recovered panic in the error
+// chain, or PanicKindNone if there is none.
+func PanicKindOf(err error) PanicKind {
+	for err != nil {
+		if panicker, ok := err.(interface{ PanicKind() PanicKind }); ok {
+			if kind := panicker.PanicKind(); kind != PanicKindNone {
+				return kind
+			}
+		}
+		unwrapper, ok := err.(Unwrapper)
+		if !ok {
+			break
+		}
+		err = unwrapper.Unwrap()
+	}
+	return PanicKindNone
+}
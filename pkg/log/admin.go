@@ -0,0 +1,206 @@
+package log
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+	"code.internetisalie.net/slogan/pkg/errors/problem"
+)
+
+// LevelAdminLogger is the JSON representation of a logger's level.
+type LevelAdminLogger struct {
+	Name       string     `json:"name"`
+	Level      string     `json:"level"`
+	Configured bool       `json:"configured"`
+	Expires    *time.Time `json:"expires,omitempty"`
+}
+
+// LevelAdminUpdate is the JSON request to change a logger's level.  An empty
+// level clears the logger's configured level.  A TTL such as "15m" reverts
+// the change after the given duration.
+type LevelAdminUpdate struct {
+	Name  string `json:"name,omitempty"`
+	Level string `json:"level"`
+	TTL   string `json:"ttl,omitempty"`
+}
+
+type levelAdminList struct {
+	Loggers []LevelAdminLogger `json:"loggers"`
+}
+
+// maxLevelAdminBodySize bounds the size of update requests
+const maxLevelAdminBodySize = 64 << 10
+
+// LevelAdminRootPath addresses the root logger, named RootLoggerName, in
+// the single-logger routes of LevelAdminHandler.
+const LevelAdminRootPath = "~"
+
+// LevelAdminHandler lists and changes logger levels at runtime:
+//
+//	GET          /         lists every logger
+//	GET          /{name}   shows one logger, or the root logger for /~
+//	PUT, PATCH   /{name}   changes one logger from a LevelAdminUpdate
+//	PATCH        /         changes several loggers from a list of LevelAdminUpdate
+//
+// Mount it with http.StripPrefix.
+type LevelAdminHandler struct{}
+
+func NewLevelAdminHandler() *LevelAdminHandler {
+	return new(LevelAdminHandler)
+}
+
+func (h *LevelAdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	problem.HandlerFunc(h.serve).ServeHTTP(w, r)
+}
+
+func (h *LevelAdminHandler) serve(w http.ResponseWriter, r *http.Request) error {
+	path := strings.Trim(r.URL.Path, "/")
+	name := path
+	if path == LevelAdminRootPath {
+		name = RootLoggerName
+	}
+
+	switch {
+	case r.Method == http.MethodGet && path == "":
+		return writeLevelAdminJSON(w, levelAdminList{Loggers: levelAdminLoggers()})
+
+	case r.Method == http.MethodGet:
+		logger, ok := levelAdminLogger(name)
+		if !ok {
+			return errors.NewCodeError(errors.CodeNotFound, nil, "unknown logger %q", name)
+		}
+		return writeLevelAdminJSON(w, logger)
+
+	case (r.Method == http.MethodPut || r.Method == http.MethodPatch) && path != "":
+		var update LevelAdminUpdate
+		if err := decodeLevelAdminJSON(r, &update); err != nil {
+			return err
+		}
+		update.Name = name
+		if err := applyLevelAdminUpdates([]LevelAdminUpdate{update}); err != nil {
+			return err
+		}
+		logger, _ := levelAdminLogger(name)
+		return writeLevelAdminJSON(w, logger)
+
+	case r.Method == http.MethodPatch:
+		var updates []LevelAdminUpdate
+		if err := decodeLevelAdminJSON(r, &updates); err != nil {
+			return err
+		}
+		if err := applyLevelAdminUpdates(updates); err != nil {
+			return err
+		}
+		return writeLevelAdminJSON(w, levelAdminList{Loggers: levelAdminLoggers()})
+
+	default:
+		w.Header().Set("Allow", "GET, PUT, PATCH")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return nil
+	}
+}
+
+// levelAdminLoggers returns every logger
+func levelAdminLoggers() []LevelAdminLogger {
+	statuses := LoggerLevels()
+	result := make([]LevelAdminLogger, len(statuses))
+	for i, status := range statuses {
+		result[i] = newLevelAdminLogger(status)
+	}
+	return result
+}
+
+// levelAdminLogger returns the named logger, if it is registered or
+// configured.  The root logger always exists.
+func levelAdminLogger(name string) (LevelAdminLogger, bool) {
+	for _, status := range LoggerLevels() {
+		if status.Name == name {
+			return newLevelAdminLogger(status), true
+		}
+	}
+
+	// an unregistered logger whose configuration was cleared, or the root
+	// logger before it is configured
+	level, _ := GetLoggerLevel(name)
+	return LevelAdminLogger{Name: name, Level: LevelName(level)}, name == RootLoggerName
+}
+
+func newLevelAdminLogger(status LoggerLevelStatus) LevelAdminLogger {
+	result := LevelAdminLogger{
+		Name:       status.Name,
+		Level:      LevelName(status.Level),
+		Configured: status.Configured,
+	}
+	if !status.Expires.IsZero() {
+		expires := status.Expires.UTC()
+		result.Expires = &expires
+	}
+	return result
+}
+
+// applyLevelAdminUpdates validates every update before applying any of them
+func applyLevelAdminUpdates(updates []LevelAdminUpdate) error {
+	validation := errors.NewValidation()
+	levels := make([]slog.Level, len(updates))
+	ttls := make([]time.Duration, len(updates))
+
+	for i, update := range updates {
+		field := validation.Index(i)
+		if update.Level != "" {
+			var err error
+			if levels[i], err = ParseLevel(update.Level); err != nil {
+				field.Addf("level", "unknown level %q", update.Level)
+			}
+		}
+		if update.TTL != "" {
+			var err error
+			if ttls[i], err = time.ParseDuration(update.TTL); err != nil || ttls[i] <= 0 {
+				field.Addf("ttl", "invalid duration %q", update.TTL)
+			}
+		}
+	}
+
+	if err := validation.Err(); err != nil {
+		return err
+	}
+
+	for i, update := range updates {
+		switch {
+		case update.Level == "":
+			ClearLoggerLevel(update.Name)
+		case ttls[i] > 0:
+			SetLoggerLevelFor(update.Name, levels[i], ttls[i])
+		default:
+			SetLoggerLevel(update.Name, levels[i])
+		}
+	}
+
+	return nil
+}
+
+func decodeLevelAdminJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxLevelAdminBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return errors.NewCodeError(errors.CodeInvalidArgument, err, "invalid request body")
+	}
+	return nil
+}
+
+// writeLevelAdminJSON encodes v before writing any of the response, so that
+// an encoding failure can still be reported as a problem.  Once the response
+// has started, there is no way to report a failure to the client.
+func writeLevelAdminJSON(w http.ResponseWriter, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to encode response")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(append(body, '\n'))
+	return nil
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"code.internetisalie.net/slogan/pkg/errors/problem"
+)
+
+func levelAdminRequest(t *testing.T, method, url, body string, v any) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	assert.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	if v != nil {
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	}
+	return resp
+}
+
+func TestLevelAdminHandler(t *testing.T) {
+	resetLoggerLevels(t)
+
+	pool := GetLoggerLeveler("svc.db.pool")
+	GetLoggerLeveler("svc.http")
+
+	server := httptest.NewServer(http.StripPrefix("/loggers", NewLevelAdminHandler()))
+	defer server.Close()
+
+	var list levelAdminList
+	levelAdminRequest(t, http.MethodGet, server.URL+"/loggers/", "", &list)
+	assert.Equal(t, []LevelAdminLogger{
+		{Name: "svc.db.pool", Level: "INFO"},
+		{Name: "svc.http", Level: "INFO"},
+	}, list.Loggers)
+
+	var logger LevelAdminLogger
+	resp := levelAdminRequest(t, http.MethodPut, server.URL+"/loggers/svc.db", `{"level":"debug"}`, &logger)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, LevelAdminLogger{Name: "svc.db", Level: "DEBUG", Configured: true}, logger)
+	assert.Equal(t, LevelDebug, pool.Level())
+
+	resp = levelAdminRequest(t, http.MethodPatch, server.URL+"/loggers/",
+		`[{"name":"svc.db.pool","level":"trace","ttl":"50ms"},{"name":"svc.db","level":""}]`, &list)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, LevelTrace, pool.Level())
+	assert.NotNil(t, list.Loggers[0].Expires)
+
+	assert.Eventually(t, func() bool {
+		return pool.Level() == LevelInfo
+	}, time.Second, 10*time.Millisecond)
+
+	var p problem.Problem
+	resp = levelAdminRequest(t, http.MethodPatch, server.URL+"/loggers/",
+		`[{"name":"svc.http","level":"loud"}]`, &p)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "[0].level", p.InvalidParams[0].Name)
+
+	resp = levelAdminRequest(t, http.MethodGet, server.URL+"/loggers/missing", "", &p)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// the root logger, named ""
+	resp = levelAdminRequest(t, http.MethodGet, server.URL+"/loggers/~", "", &logger)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, LevelAdminLogger{Name: RootLoggerName, Level: "INFO"}, logger)
+
+	resp = levelAdminRequest(t, http.MethodPut, server.URL+"/loggers/~", `{"level":"warn"}`, &logger)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, LevelAdminLogger{Name: RootLoggerName, Level: "WARN", Configured: true}, logger)
+	assert.Equal(t, LevelWarn, GetLoggerLeveler("svc.http").Level())
+}
@@ -0,0 +1,237 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+type AsyncOptions struct {
+	// QueueSize bounds the records awaiting the wrapped handler.  Defaults
+	// to 1024.
+	QueueSize int
+	// Overflow chooses which records to drop when the queue is full.
+	// Defaults to OverflowDropNewest.
+	Overflow OverflowPolicy
+	// DropLevel is the level below which OverflowDropBelowLevel drops
+	// records.  Defaults to LevelWarn.
+	DropLevel slog.Leveler
+}
+
+// AsyncHandler passes records to a wrapped handler from a background
+// goroutine, so that a slow writer does not stall the caller.  Records are
+// handled in order by the wrapped handler, with the attributes and groups
+// in effect when they were logged.
+type AsyncHandler struct {
+	queue *asyncQueue
+	next  slog.Handler
+}
+
+type asyncRecord struct {
+	ctx     context.Context
+	handler slog.Handler
+	record  slog.Record
+}
+
+// asyncQueue is a ring buffer of records shared by an AsyncHandler and the
+// handlers derived from it
+type asyncQueue struct {
+	opts     AsyncOptions
+	mu       *sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond
+	records  []asyncRecord
+	head     int
+	count    int
+	pending  int // queued or being handled
+	closed   bool
+	dropped  atomic.Uint64
+	stopped  chan struct{}
+
+	unregister func()
+}
+
+func NewAsyncHandler(next slog.Handler, opts *AsyncOptions) *AsyncHandler {
+	q := &asyncQueue{
+		mu:      &sync.Mutex{},
+		stopped: make(chan struct{}),
+	}
+	if opts != nil {
+		q.opts = *opts
+	}
+	if q.opts.QueueSize <= 0 {
+		q.opts.QueueSize = 1024
+	}
+	if q.opts.DropLevel == nil {
+		q.opts.DropLevel = LevelWarn
+	}
+	q.records = make([]asyncRecord, q.opts.QueueSize)
+	q.notEmpty = sync.NewCond(q.mu)
+	q.notFull = sync.NewCond(q.mu)
+	q.idle = sync.NewCond(q.mu)
+
+	go q.run()
+
+	h := &AsyncHandler{queue: q, next: next}
+	q.unregister = RegisterLifecycle(h)
+	return h
+}
+
+func (h *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *AsyncHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &AsyncHandler{queue: h.queue, next: h.next.WithGroup(name)}
+}
+
+func (h *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &AsyncHandler{queue: h.queue, next: h.next.WithAttrs(attrs)}
+}
+
+// Handle queues the record, or handles it directly once the handler is closed.
+func (h *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	detached := context.Background()
+	if ctx != nil {
+		detached = context.WithoutCancel(ctx)
+	}
+
+	item := asyncRecord{
+		ctx:     detached,
+		handler: h.next,
+		record:  r.Clone(),
+	}
+	if !h.queue.push(item) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+// Dropped returns the number of records dropped because the queue was full.
+func (h *AsyncHandler) Dropped() uint64 {
+	return h.queue.dropped.Load()
+}
+
+// Flush waits until every queued record has been handled, or ctx is done.
+func (h *AsyncHandler) Flush(ctx context.Context) error {
+	return h.queue.flush(ctx)
+}
+
+// Close flushes the queue and stops the background goroutine, waiting until
+// ctx is done.  Records logged afterwards are handled synchronously, and
+// any still queued when ctx is done are handled in the background.
+func (h *AsyncHandler) Close(ctx context.Context) error {
+	h.queue.unregister()
+	err := h.queue.flush(ctx)
+
+	h.queue.mu.Lock()
+	h.queue.closed = true
+	h.queue.notEmpty.Broadcast()
+	h.queue.notFull.Broadcast()
+	h.queue.mu.Unlock()
+
+	select {
+	case <-h.queue.stopped:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// push queues a record according to the overflow policy, returning false
+// if the queue is closed
+func (q *asyncQueue) push(item asyncRecord) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && q.count == len(q.records) {
+		switch q.opts.Overflow {
+		case OverflowDropNewest:
+			q.dropped.Add(1)
+			return true
+		case OverflowDropOldest:
+			q.records[q.head] = asyncRecord{}
+			q.head = (q.head + 1) % len(q.records)
+			q.count--
+			q.pending--
+			q.dropped.Add(1)
+		case OverflowDropBelowLevel:
+			if item.record.Level < q.opts.DropLevel.Level() {
+				q.dropped.Add(1)
+				return true
+			}
+			q.notFull.Wait()
+		default:
+			q.notFull.Wait()
+		}
+	}
+
+	if q.closed {
+		return false
+	}
+
+	q.records[(q.head+q.count)%len(q.records)] = item
+	q.count++
+	q.pending++
+	q.notEmpty.Signal()
+	return true
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.stopped)
+
+	for {
+		q.mu.Lock()
+		for q.count == 0 && !q.closed {
+			q.notEmpty.Wait()
+		}
+		if q.count == 0 {
+			q.mu.Unlock()
+			return
+		}
+
+		item := q.records[q.head]
+		q.records[q.head] = asyncRecord{}
+		q.head = (q.head + 1) % len(q.records)
+		q.count--
+		q.notFull.Signal()
+		q.mu.Unlock()
+
+		_ = item.handler.Handle(item.ctx, item.record)
+
+		q.mu.Lock()
+		q.pending--
+		if q.pending == 0 {
+			q.idle.Broadcast()
+		}
+		q.mu.Unlock()
+	}
+}
+
+func (q *asyncQueue) flush(ctx context.Context) error {
+	// wake the wait below when ctx is done
+	stop := context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.idle.Broadcast()
+	})
+	defer stop()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.pending > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		q.idle.Wait()
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gatedHandler records messages, blocking each Handle until released
+type gatedHandler struct {
+	mu       *sync.Mutex
+	started  chan string
+	release  chan struct{}
+	messages *[]string
+}
+
+func newGatedHandler() *gatedHandler {
+	return &gatedHandler{
+		mu:       &sync.Mutex{},
+		started:  make(chan string, 100),
+		release:  make(chan struct{}),
+		messages: new([]string),
+	}
+}
+
+func (h *gatedHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *gatedHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *gatedHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *gatedHandler) Handle(_ context.Context, r slog.Record) error {
+	h.started <- r.Message
+	<-h.release
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.messages = append(*h.messages, r.Message)
+	return nil
+}
+
+func (h *gatedHandler) snapshot() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), *h.messages...)
+}
+
+func TestAsyncHandler(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	h := NewAsyncHandler(slog.NewTextHandler(buffer, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}), nil)
+
+	logger := slog.New(h).With("a", 1).WithGroup("g")
+	logger.Info("one", "b", 2)
+	slog.New(h).Info("two")
+
+	assert.NoError(t, h.Flush(context.Background()))
+	assert.Equal(t, "level=INFO msg=one a=1 g.b=2\nlevel=INFO msg=two\n", buffer.String())
+
+	assert.NoError(t, h.Close(context.Background()))
+	logger.Info("after close")
+	assert.Contains(t, buffer.String(), "msg=\"after close\"")
+}
+
+func TestAsyncHandler_Overflow(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		overflow OverflowPolicy
+		expected []string
+		dropped  uint64
+	}{
+		{name: "DropNewest", overflow: OverflowDropNewest, expected: []string{"0", "1", "2"}, dropped: 2},
+		{name: "DropOldest", overflow: OverflowDropOldest, expected: []string{"0", "3", "W"}, dropped: 2},
+		{name: "DropBelowLevel", overflow: OverflowDropBelowLevel, expected: []string{"0", "1", "2", "W"}, dropped: 1},
+		{name: "Block", overflow: OverflowBlock, expected: []string{"0", "1", "2", "3", "W"}, dropped: 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			next := newGatedHandler()
+			h := NewAsyncHandler(next, &AsyncOptions{QueueSize: 2, Overflow: test.overflow})
+			logger := slog.New(h)
+
+			// "0" is being handled, and "1" and "2" fill the queue
+			logger.Info("0")
+			assert.Equal(t, "0", <-next.started)
+			logger.Info("1")
+			logger.Info("2")
+
+			logged := make(chan struct{})
+			go func() {
+				defer close(logged)
+				logger.Info("3")
+				logger.Warn("W")
+			}()
+
+			blocks := test.overflow == OverflowBlock || test.overflow == OverflowDropBelowLevel
+			select {
+			case <-logged:
+				assert.False(t, blocks, "logging did not block")
+			case <-time.After(50 * time.Millisecond):
+				assert.True(t, blocks, "logging blocked")
+			}
+
+			close(next.release)
+			<-logged
+			assert.NoError(t, h.Close(context.Background()))
+
+			assert.Equal(t, test.expected, next.snapshot())
+			assert.Equal(t, test.dropped, h.Dropped())
+		})
+	}
+}
+
+func TestAsyncHandler_FlushTimeout(t *testing.T) {
+	next := newGatedHandler()
+	h := NewAsyncHandler(next, nil)
+	slog.New(h).Info("stuck")
+	<-next.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, h.Flush(ctx), context.DeadlineExceeded)
+
+	close(next.release)
+	assert.NoError(t, h.Close(context.Background()))
+}
+
+func TestAsyncHandler_CloseTimeout(t *testing.T) {
+	next := newGatedHandler()
+	defer close(next.release)
+
+	h := NewAsyncHandler(next, nil)
+	slog.New(h).Info("stuck")
+	<-next.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	closed := make(chan error)
+	go func() { closed <- h.Close(ctx) }()
+
+	select {
+	case err := <-closed:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("Close ignored its deadline")
+	}
+}
@@ -215,3 +215,25 @@ func ReflectValue(rv reflect.Value) slog.Value {
 
 	return slog.AnyValue(rv.Interface())
 }
+
+// walkAttrs calls fn with the group path and resolved value of every
+// non-empty, non-group attribute in the tree
+func walkAttrs(path []string, attrs []slog.Attr, fn func(path []string, value slog.Value)) {
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		if attr.Key == "" && value.Kind() != slog.KindGroup {
+			continue
+		}
+
+		attrPath := path
+		if attr.Key != "" {
+			attrPath = AddGroup(path, attr.Key)
+		}
+
+		if value.Kind() == slog.KindGroup {
+			walkAttrs(attrPath, value.Group(), fn)
+			continue
+		}
+		fn(attrPath, value)
+	}
+}
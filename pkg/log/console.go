@@ -0,0 +1,164 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+const (
+	OutputStdout  = "stdout"
+	OutputStderr  = "stderr"
+	OutputSplit   = "split"
+	OutputDefault = OutputStdout
+)
+
+type ConsoleOptions struct {
+	// Writer receives records, or only those below SplitLevel if ErrorWriter
+	// is set.  Defaults to os.Stdout.
+	Writer io.Writer
+	// ErrorWriter, if set, receives records at SplitLevel and above.
+	ErrorWriter io.Writer
+	// SplitLevel defaults to LevelWarn.
+	SplitLevel slog.Leveler
+	// Format overrides the per-writer format detection.
+	Format string
+}
+
+var (
+	consoleFiles     = make(map[string]*os.File)
+	consoleFilesLock sync.Mutex
+)
+
+// openConsoleFile opens a log file for appending, once per path.  The file
+// is synced by Flush, and closed by Shutdown.
+func openConsoleFile(path string) (*os.File, error) {
+	consoleFilesLock.Lock()
+	defer consoleFilesLock.Unlock()
+
+	if file, ok := consoleFiles[path]; ok {
+		return file, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, errors.WrapSentinel(err, "failed to open log file")
+	}
+	consoleFiles[path] = file
+
+	// devices such as /dev/stderr cannot be synced
+	info, err := file.Stat()
+	regular := err == nil && info.Mode().IsRegular()
+
+	var unregister func()
+	unregister = RegisterLifecycle(LifecycleFuncs{
+		FlushFunc: func(context.Context) error {
+			if !regular {
+				return nil
+			}
+			if err := file.Sync(); err != nil {
+				return errors.WrapSentinel(err, "failed to sync log file")
+			}
+			return nil
+		},
+		CloseFunc: func(context.Context) error {
+			unregister()
+			consoleFilesLock.Lock()
+			delete(consoleFiles, path)
+			consoleFilesLock.Unlock()
+			if err := file.Close(); err != nil {
+				return errors.WrapSentinel(err, "failed to close log file")
+			}
+			return nil
+		},
+	})
+	return file, nil
+}
+
+// ConsoleOptionsFromEnv returns console options configured by LOG_FORMAT and
+// LOG_OUTPUT, which is one of `stdout`, `stderr`, `split` (Warn and above to
+// stderr, the rest to stdout), or the path of a file to append to.
+func ConsoleOptionsFromEnv() ConsoleOptions {
+	opts := ConsoleOptions{
+		Format: requestedConsoleFormat(),
+	}
+
+	requestedOutput := os.Getenv("LOG_OUTPUT")
+	switch strings.ToLower(requestedOutput) {
+	case "", OutputStdout:
+		opts.Writer = os.Stdout
+	case OutputStderr:
+		opts.Writer = os.Stderr
+	case OutputSplit:
+		opts.Writer = os.Stdout
+		opts.ErrorWriter = os.Stderr
+	default:
+		file, err := openConsoleFile(requestedOutput)
+		if err != nil {
+			opts.Writer = os.Stdout
+			_ = os.Setenv("LOG_OUTPUT", OutputDefault)
+			StandardLogger().Error(fmt.Sprintf(
+				"Unable to open log output %q: %v.  Defaulting to %q",
+				requestedOutput, err, OutputDefault))
+			break
+		}
+		opts.Writer = file
+	}
+
+	return opts
+}
+
+// isTerminal reports whether w is a file attached to a terminal
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd())
+}
+
+// splitHandler sends records at or above level to high, and the rest to low
+type splitHandler struct {
+	low   slog.Handler
+	high  slog.Handler
+	level slog.Leveler
+}
+
+func (h *splitHandler) handler(level slog.Level) slog.Handler {
+	if level >= h.level.Level() {
+		return h.high
+	}
+	return h.low
+}
+
+func (h *splitHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler(level).Enabled(ctx, level)
+}
+
+func (h *splitHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.handler(record.Level).Handle(ctx, record)
+}
+
+func (h *splitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &splitHandler{
+		low:   h.low.WithAttrs(attrs),
+		high:  h.high.WithAttrs(attrs),
+		level: h.level,
+	}
+}
+
+func (h *splitHandler) WithGroup(name string) slog.Handler {
+	return &splitHandler{
+		low:   h.low.WithGroup(name),
+		high:  h.high.WithGroup(name),
+		level: h.level,
+	}
+}
@@ -0,0 +1,70 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConsoleHandlerWithOptions_Split(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	logger := slog.New(NewConsoleHandlerWithOptions(&slog.HandlerOptions{Level: LevelDebug}, ConsoleOptions{
+		Writer:      stdout,
+		ErrorWriter: stderr,
+		Format:      FormatPlain,
+	}))
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	assert.Equal(t, "debug\ninfo\n", stdout.String())
+	assert.Equal(t, "warn\nerror\n", stderr.String())
+}
+
+func TestRequestedConsoleFormat_Unknown(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "loud")
+	assert.Empty(t, requestedConsoleFormat())
+	assert.Equal(t, consoleFormat(os.Stdout, ""), os.Getenv("LOG_FORMAT"), "replaced by the default")
+}
+
+func TestConsoleOptionsFromEnv(t *testing.T) {
+	t.Setenv("LOG_FORMAT", FormatJson)
+
+	t.Setenv("LOG_OUTPUT", OutputSplit)
+	opts := ConsoleOptionsFromEnv()
+	assert.Equal(t, os.Stdout, opts.Writer)
+	assert.Equal(t, os.Stderr, opts.ErrorWriter)
+	assert.Equal(t, FormatJson, opts.Format)
+
+	isolateLifecycles(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv("LOG_OUTPUT", path)
+	opts = ConsoleOptionsFromEnv()
+	assert.Nil(t, opts.ErrorWriter)
+
+	registered := registeredLifecycles()
+	if !assert.Len(t, registered, 1, "file lifecycle registered") {
+		return
+	}
+	lifecycle := registered[0]
+
+	slog.New(NewConsoleHandlerWithOptions(&slog.HandlerOptions{}, opts)).Info("to file")
+	assert.NoError(t, lifecycle.Flush(context.Background()))
+	contents, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), `"msg":"to file"`)
+
+	assert.NoError(t, lifecycle.Close(context.Background()))
+	assert.NotContains(t, consoleFiles, path)
+	_, err = opts.Writer.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
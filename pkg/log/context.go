@@ -22,3 +22,48 @@ func logAttrsFromContext(ctx context.Context) []slog.Attr {
 	v, _ := ctx.Value(contextKeyLogAttrs).([]slog.Attr)
 	return v
 }
+
+const contextKeyLogLevel = contextKey("LogLevel")
+
+// ContextWithLevel overrides the level of every logger for records logged with the returned context.
+func ContextWithLevel(ctx context.Context, level slog.Level) context.Context {
+	return context.WithValue(ctx, contextKeyLogLevel, level)
+}
+
+// levelFromContext returns the level override carried by ctx, which may be nil
+func levelFromContext(ctx context.Context) (slog.Level, bool) {
+	if ctx == nil {
+		return LevelInfo, false
+	}
+	level, ok := ctx.Value(contextKeyLogLevel).(slog.Level)
+	return level, ok
+}
+
+// ContextLevelHandler applies any level override carried by the context
+// before deferring to the next handler.
+type ContextLevelHandler struct {
+	next slog.Handler
+}
+
+func NewContextLevelHandler(next slog.Handler) *ContextLevelHandler {
+	return &ContextLevelHandler{next: next}
+}
+
+func (h *ContextLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if override, ok := levelFromContext(ctx); ok {
+		return level >= override
+	}
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *ContextLevelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.next.Handle(ctx, record)
+}
+
+func (h *ContextLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextLevelHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *ContextLevelHandler) WithGroup(name string) slog.Handler {
+	return &ContextLevelHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,498 @@
+package log
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+const (
+	// rotatedFileTimeFormat names rotated segments, and sorts oldest first
+	rotatedFileTimeFormat = "20060102T150405.000000000"
+	compressedFileSuffix  = ".gz"
+)
+
+var errFileClosed = errors.NewSentinel("log file closed")
+
+type RotatingFileOptions struct {
+	// Path of the active log file.  Rotated segments are kept beside it as
+	// <Path>.<timestamp>, with a .gz suffix when compressed.
+	Path string
+	// MaxSize rotates the file before a write would grow it beyond this many
+	// bytes.  Zero disables size-based rotation.
+	MaxSize int64
+	// Interval rotates the file when the wall clock crosses a multiple of the
+	// interval, such as 24h for daily files.  Zero disables time-based rotation.
+	Interval time.Duration
+	// Compress gzips rotated segments.
+	Compress bool
+	// MaxBackups removes the oldest rotated segments beyond this count.  Zero
+	// keeps every segment.
+	MaxBackups int
+	// MaxAge removes rotated segments last written longer ago than this.  Zero
+	// keeps every segment.
+	MaxAge time.Duration
+}
+
+// RotatingFile is an io.Writer appending to a log file that is rotated by
+// size and time.  Compression and retention of rotated segments happen in
+// the background.
+type RotatingFile struct {
+	opts   RotatingFileOptions
+	mu     *sync.Mutex
+	file   *os.File // nil after a failed rotation, until reopened
+	size   int64
+	opened time.Time
+	closed bool
+	mill   chan struct{}
+	milled chan struct{}
+
+	unregister func()
+}
+
+func NewRotatingFile(opts RotatingFileOptions) (*RotatingFile, error) {
+	f := &RotatingFile{
+		opts:   opts,
+		mu:     &sync.Mutex{},
+		mill:   make(chan struct{}, 1),
+		milled: make(chan struct{}),
+	}
+
+	if err := f.openLocked(); err != nil {
+		return nil, err
+	}
+
+	go f.runMill()
+	f.mill <- struct{}{} // apply retention to segments from earlier runs
+
+	f.unregister = RegisterLifecycle(LifecycleFuncs{
+		FlushFunc: func(context.Context) error { return f.Sync() },
+		CloseFunc: func(context.Context) error { return f.Close() },
+	})
+	return f, nil
+}
+
+// Write appends p to the file, rotating it first if due.  If rotation
+// fails, p is still appended to the file if it remains open, and the
+// rotation error is returned.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, errors.WrapSentinel(errFileClosed, f.opts.Path)
+	}
+
+	var rotateErr error
+	if f.file == nil {
+		rotateErr = f.openLocked()
+	} else if f.shouldRotateLocked(len(p), time.Now()) {
+		rotateErr = f.rotateLocked()
+	}
+	if f.file == nil {
+		return 0, rotateErr
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, errors.Join(rotateErr, err)
+}
+
+// Sync commits the active file to disk.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	if err := f.file.Sync(); err != nil {
+		return errors.WrapSentinel(err, "failed to sync log file")
+	}
+	return nil
+}
+
+// Rotate moves the active file aside and starts a new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return errors.WrapSentinel(errFileClosed, f.opts.Path)
+	}
+	return f.rotateLocked()
+}
+
+// Reopen opens the file at Path in place of the active file, for use after
+// an external tool such as logrotate has moved it aside.  If the file cannot
+// be opened, the active file is kept.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return errors.WrapSentinel(errFileClosed, f.opts.Path)
+	}
+	return f.openLocked()
+}
+
+// Close closes the file, and waits for any background compression and
+// retention to complete.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	file := f.file
+	f.file = nil
+	f.mu.Unlock()
+
+	f.unregister()
+	var err error
+	if file != nil {
+		if err = file.Close(); err != nil {
+			err = errors.WrapSentinel(err, "failed to close log file")
+		}
+	}
+	close(f.mill)
+	<-f.milled
+	return err
+}
+
+func (f *RotatingFile) shouldRotateLocked(n int, now time.Time) bool {
+	if f.opts.MaxSize > 0 && f.size > 0 && f.size+int64(n) > f.opts.MaxSize {
+		return true
+	}
+	if f.opts.Interval > 0 && !now.Truncate(f.opts.Interval).Equal(f.opened.Truncate(f.opts.Interval)) {
+		return true
+	}
+	return false
+}
+
+// openLocked opens the file at Path, replacing the active file only once
+// the new one is open
+func (f *RotatingFile) openLocked() error {
+	if err := os.MkdirAll(filepath.Dir(f.opts.Path), 0o755); err != nil {
+		return errors.WrapSentinel(err, "failed to create log directory")
+	}
+
+	file, err := os.OpenFile(f.opts.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to open log file")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return errors.WrapSentinel(err, "failed to open log file")
+	}
+
+	var closeErr error
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			closeErr = errors.WrapSentinel(err, "failed to close log file")
+		}
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.opened = time.Now()
+	if f.size > 0 {
+		// continue the rotation interval of an existing file
+		f.opened = info.ModTime()
+	}
+	return closeErr
+}
+
+// rotateLocked moves the active file aside and opens a new one.  The file is
+// closed before it is renamed, for platforms that cannot rename open files,
+// so a failure leaves no active file, and the next write reopens it.
+func (f *RotatingFile) rotateLocked() error {
+	if f.file != nil {
+		err := f.file.Close()
+		f.file = nil
+		if err != nil {
+			return errors.Join(errors.WrapSentinel(err, "failed to close log file"), f.openLocked())
+		}
+	}
+
+	if err := os.Rename(f.opts.Path, f.rotatedName(time.Now())); err != nil && !os.IsNotExist(err) {
+		// keep appending to the unrotated file
+		return errors.Join(errors.WrapSentinel(err, "failed to rotate log file"), f.openLocked())
+	}
+
+	if err := f.openLocked(); err != nil {
+		return err
+	}
+
+	select {
+	case f.mill <- struct{}{}:
+	default: // already pending
+	}
+	return nil
+}
+
+// rotatedName returns an unused name for a segment rotated at the given time
+func (f *RotatingFile) rotatedName(t time.Time) string {
+	for {
+		name := f.opts.Path + "." + t.UTC().Format(rotatedFileTimeFormat)
+		_, err := os.Lstat(name)
+		_, errCompressed := os.Lstat(name + compressedFileSuffix)
+		if err != nil && errCompressed != nil {
+			// unused, or unusable for reasons the rename reports
+			return name
+		}
+		t = t.Add(time.Nanosecond)
+	}
+}
+
+func (f *RotatingFile) runMill() {
+	defer close(f.milled)
+	for range f.mill {
+		if err := f.millOnce(); err != nil {
+			LoggingLogger().Error("Failed to maintain rotated log files",
+				"path", f.opts.Path,
+				ErrorKey, err)
+		}
+	}
+}
+
+// millOnce compresses rotated segments and removes those beyond retention
+func (f *RotatingFile) millOnce() error {
+	segments, err := f.rotatedSegments()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if f.opts.Compress {
+		for i, segment := range segments {
+			if strings.HasSuffix(segment.path, compressedFileSuffix) {
+				continue
+			}
+			if err := compressFile(segment.path); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			segments[i].path += compressedFileSuffix
+		}
+	}
+
+	cutoff := time.Now().Add(-f.opts.MaxAge)
+	kept := 0
+	// newest first
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment := segments[i]
+		expired := f.opts.MaxAge > 0 && segment.modTime.Before(cutoff)
+		excess := f.opts.MaxBackups > 0 && kept >= f.opts.MaxBackups
+		if !expired && !excess {
+			kept++
+			continue
+		}
+		if err := os.Remove(segment.path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, errors.WrapSentinel(err, "failed to remove rotated log file"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type rotatedSegment struct {
+	path    string
+	modTime time.Time
+}
+
+// rotatedSegments lists the rotated segments of the file, oldest first
+func (f *RotatingFile) rotatedSegments() ([]rotatedSegment, error) {
+	dir := filepath.Dir(f.opts.Path)
+	prefix := filepath.Base(f.opts.Path) + "."
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.WrapSentinel(err, "failed to list rotated log files")
+	}
+
+	var result []rotatedSegment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressedFileSuffix)
+		if _, err := time.Parse(rotatedFileTimeFormat, stamp); err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		result = append(result, rotatedSegment{
+			path:    filepath.Join(dir, name),
+			modTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].path < result[j].path
+	})
+	return result, nil
+}
+
+// compressFile replaces path with a gzipped copy, preserving its modification time
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to open rotated log file")
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to open rotated log file")
+	}
+
+	dst, err := os.OpenFile(path+compressedFileSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to create compressed log file")
+	}
+	defer func() {
+		if err != nil {
+			_ = dst.Close()
+			_ = os.Remove(dst.Name())
+		}
+	}()
+
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err != nil {
+		return errors.WrapSentinel(err, "failed to compress log file")
+	}
+	if err = zw.Close(); err != nil {
+		return errors.WrapSentinel(err, "failed to compress log file")
+	}
+	if err = dst.Close(); err != nil {
+		return errors.WrapSentinel(err, "failed to close compressed log file")
+	}
+	if err = os.Chtimes(dst.Name(), info.ModTime(), info.ModTime()); err != nil {
+		return errors.WrapSentinel(err, "failed to set compressed log file time")
+	}
+
+	_ = src.Close()
+	if err := os.Remove(path); err != nil {
+		return errors.WrapSentinel(err, "failed to remove compressed log file")
+	}
+	return nil
+}
+
+// FileHandlerFactory creates the handler for a logger's file output.  The
+// options carry the logger's level and attribute replacers.
+type FileHandlerFactory func(ho *slog.HandlerOptions) slog.Handler
+
+var (
+	registeredFileHandlerFactory atomic.Pointer[FileHandlerFactory]
+	// fileHandlerGeneration counts registrations, invalidating cached handlers
+	fileHandlerGeneration atomic.Uint64
+)
+
+func RegisterFileHandlerFactory(factory FileHandlerFactory) {
+	if factory == nil {
+		registeredFileHandlerFactory.Store(nil)
+	} else {
+		registeredFileHandlerFactory.Store(&factory)
+	}
+	fileHandlerGeneration.Add(1)
+}
+
+// NewFileHandlerFactory returns a factory writing records to w in the given
+// format, or the default format if empty.
+func NewFileHandlerFactory(w io.Writer, format string) FileHandlerFactory {
+	return func(ho *slog.HandlerOptions) slog.Handler {
+		return newConsoleWriterHandler(w, format, ho)
+	}
+}
+
+// NewFileHandler returns a handler forwarding to the registered file handler
+// factory, if any.
+func NewFileHandler(ho *slog.HandlerOptions) slog.Handler {
+	return NewContextLevelHandler(&FileProxyHandler{ho: ho})
+}
+
+// FileProxyHandler forwards records to a handler created by the registered
+// file handler factory.  The handler is created once for its attributes and
+// groups, and again only when another factory is registered.
+type FileProxyHandler struct {
+	ho     *slog.HandlerOptions
+	parent *FileProxyHandler // nil for the handler created by the factory
+	attrs  []slog.Attr       // added to the parent's handler
+	group  string            // opened on the parent's handler, if not empty
+	leaf   atomic.Pointer[fileLeafHandler]
+}
+
+type fileLeafHandler struct {
+	generation uint64
+	handler    slog.Handler // nil without a registered factory
+}
+
+func (h *FileProxyHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if n := h.leafHandler(); n != nil {
+		return n.Enabled(ctx, level)
+	}
+	return false
+}
+
+func (h *FileProxyHandler) Handle(ctx context.Context, record slog.Record) error {
+	if n := h.leafHandler(); n != nil {
+		return n.Handle(ctx, record)
+	}
+	return nil
+}
+
+func (h *FileProxyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &FileProxyHandler{ho: h.ho, parent: h, attrs: attrs}
+}
+
+func (h *FileProxyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &FileProxyHandler{ho: h.ho, parent: h, group: name}
+}
+
+func (h *FileProxyHandler) leafHandler() slog.Handler {
+	generation := fileHandlerGeneration.Load()
+	if leaf := h.leaf.Load(); leaf != nil && leaf.generation == generation {
+		return leaf.handler
+	}
+
+	leaf := &fileLeafHandler{generation: generation}
+	switch {
+	case h.parent == nil:
+		if factory := registeredFileHandlerFactory.Load(); factory != nil {
+			leaf.handler = (*factory)(h.ho)
+		}
+	case h.group != "":
+		if n := h.parent.leafHandler(); n != nil {
+			leaf.handler = n.WithGroup(h.group)
+		}
+	default:
+		if n := h.parent.leafHandler(); n != nil {
+			leaf.handler = n.WithAttrs(h.attrs)
+		}
+	}
+	h.leaf.Store(leaf)
+	return leaf.handler
+}
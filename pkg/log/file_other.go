@@ -0,0 +1,11 @@
+//go:build !unix
+
+package log
+
+import (
+	"context"
+	"os"
+)
+
+// ReopenOnSignal does nothing on platforms without SIGHUP.
+func (f *RotatingFile) ReopenOnSignal(ctx context.Context, sigs ...os.Signal) {}
@@ -0,0 +1,247 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+func readRotatedSegments(t *testing.T, f *RotatingFile) []string {
+	segments, err := f.rotatedSegments()
+	assert.NoError(t, err)
+
+	var result []string
+	for _, segment := range segments {
+		file, err := os.Open(segment.path)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		var r io.Reader = file
+		if strings.HasSuffix(segment.path, compressedFileSuffix) {
+			r, err = gzip.NewReader(file)
+			assert.NoError(t, err)
+		}
+		data, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		_ = file.Close()
+		result = append(result, string(data))
+	}
+	return result
+}
+
+func TestRotatingFile_MaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(RotatingFileOptions{Path: path, MaxSize: 10})
+	assert.NoError(t, err)
+
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n", "eeee\n"} {
+		_, err = f.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"aaaa\nbbbb\n", "cccc\ndddd\n"}, readRotatedSegments(t, f))
+	active, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "eeee\n", string(active))
+
+	_, err = f.Write([]byte("ffff\n"))
+	assert.ErrorIs(t, err, errFileClosed)
+}
+
+func TestRotatingFile_Interval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(RotatingFileOptions{Path: path, Interval: 50 * time.Millisecond})
+	assert.NoError(t, err)
+
+	_, err = f.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	time.Sleep(60 * time.Millisecond)
+	_, err = f.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"first\n"}, readRotatedSegments(t, f))
+}
+
+func TestRotatingFile_CompressAndRetain(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	// an expired segment left by an earlier run, and an unrelated file
+	expired := path + "." + time.Now().Add(-time.Hour).UTC().Format(rotatedFileTimeFormat)
+	assert.NoError(t, os.WriteFile(expired, []byte("old\n"), 0o644))
+	assert.NoError(t, os.Chtimes(expired, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+	assert.NoError(t, os.WriteFile(path+".bak", []byte("keep\n"), 0o644))
+
+	f, err := NewRotatingFile(RotatingFileOptions{
+		Path:       path,
+		Compress:   true,
+		MaxBackups: 2,
+		MaxAge:     time.Minute,
+	})
+	assert.NoError(t, err)
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		_, err = f.Write([]byte(line))
+		assert.NoError(t, err)
+		assert.NoError(t, f.Rotate())
+	}
+	assert.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"two\n", "three\n"}, readRotatedSegments(t, f))
+	segments, err := f.rotatedSegments()
+	assert.NoError(t, err)
+	for _, segment := range segments {
+		assert.True(t, strings.HasSuffix(segment.path, compressedFileSuffix), segment.path)
+	}
+	assert.FileExists(t, path+".bak")
+}
+
+func TestRotatingFile_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := NewRotatingFile(RotatingFileOptions{Path: path})
+	assert.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write([]byte("before\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, os.Rename(path, path+".1"))
+	assert.NoError(t, f.Reopen())
+	_, err = f.Write([]byte("after\n"))
+	assert.NoError(t, err)
+
+	moved, err := os.ReadFile(path + ".1")
+	assert.NoError(t, err)
+	assert.Equal(t, "before\n", string(moved))
+	active, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "after\n", string(active))
+}
+
+func TestRotatingFile_ReopenFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(RotatingFileOptions{Path: path, MaxSize: 10})
+	assert.NoError(t, err)
+
+	// a directory in place of the file cannot be opened
+	assert.NoError(t, os.Rename(path, path+".1"))
+	assert.NoError(t, os.Mkdir(path, 0o755))
+	assert.Error(t, f.Reopen())
+
+	_, err = f.Write([]byte("kept\n"))
+	assert.NoError(t, err)
+	moved, err := os.ReadFile(path + ".1")
+	assert.NoError(t, err)
+	assert.Equal(t, "kept\n", string(moved), "active file kept")
+	assert.NoError(t, os.Remove(path))
+
+	// a file in place of the directory fails rotation, leaving no active
+	// file until the path is usable again
+	assert.NoError(t, os.Rename(dir, dir+".old"))
+	assert.NoError(t, os.WriteFile(dir, nil, 0o644))
+	_, err = f.Write([]byte("rotated\n"))
+	assert.Error(t, err)
+
+	assert.NoError(t, os.Remove(dir))
+	_, err = f.Write([]byte("recovered\n"))
+	assert.NoError(t, err)
+	active, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "recovered\n", string(active))
+
+	assert.NoError(t, f.Close())
+	select {
+	case <-f.milled:
+	default:
+		t.Error("background goroutine still running")
+	}
+}
+
+func TestNewLogger_FileHandler(t *testing.T) {
+	resetLoggerLevels(t)
+	defer RegisterFileHandlerFactory(nil)
+
+	buffer := new(bytes.Buffer)
+	RegisterFileHandlerFactory(NewFileHandlerFactory(buffer, FormatJson))
+	SetLoggerLevel("svc.file", LevelWarn)
+
+	logger := NewLogger("svc.file").WithGroup("request").With("id", 1)
+	logger.Info("skipped")
+	logger.Warn("written", "status", 500)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if assert.Len(t, lines, 1) {
+		assert.Contains(t, lines[0], `"msg":"written"`)
+		assert.Contains(t, lines[0], `"logger":"svc.file"`)
+		assert.Contains(t, lines[0], `"request":{"id":1,"status":500}`)
+	}
+
+	RegisterFileHandlerFactory(nil)
+	NewLogger("svc.file").Error("dropped")
+	assert.Len(t, strings.Split(strings.TrimSpace(buffer.String()), "\n"), 1)
+	assert.False(t, slog.New(NewFileHandler(&slog.HandlerOptions{})).Enabled(context.Background(), LevelError))
+}
+
+func TestNewLogger_ContextError(t *testing.T) {
+	resetLoggerLevels(t)
+	defer RegisterFileHandlerFactory(nil)
+
+	buffer := new(bytes.Buffer)
+	RegisterFileHandlerFactory(NewFileHandlerFactory(buffer, FormatJson))
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errors.NewCodeError(errors.CodeUnavailable, nil, "shutting down"))
+
+	NewLogger("svc.file").With(ErrorKey, errors.FromContext(ctx)).Error("stopped")
+
+	var record struct {
+		Error struct {
+			BackTrace string `json:"backtrace"`
+		} `json:"error"`
+	}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+	backTrace := record.Error.BackTrace
+	assert.True(t, strings.HasPrefix(backTrace, "Root Cause: shutting down\n"), backTrace)
+	assert.Contains(t, backTrace, "Root Cause: shutting down\n  code.internetisalie.net/slogan/pkg/log.TestNewLogger_ContextError")
+	assert.Contains(t, backTrace, "Caused: context canceled\n")
+}
+
+func TestFileProxyHandler_Cached(t *testing.T) {
+	defer RegisterFileHandlerFactory(nil)
+
+	buffer := new(bytes.Buffer)
+	var created int
+	factory := NewFileHandlerFactory(buffer, FormatJson)
+	RegisterFileHandlerFactory(func(ho *slog.HandlerOptions) slog.Handler {
+		created++
+		return factory(ho)
+	})
+
+	logger := slog.New(NewFileHandler(&slog.HandlerOptions{})).With("id", 1)
+	logger.Info("one")
+	logger.Info("two")
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 2, strings.Count(buffer.String(), `"id":1`))
+
+	RegisterFileHandlerFactory(func(ho *slog.HandlerOptions) slog.Handler {
+		created++
+		return factory(ho)
+	})
+	logger.Info("three")
+	logger.Info("four")
+	assert.Equal(t, 2, created, "created again for the new factory")
+}
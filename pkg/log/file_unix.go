@@ -0,0 +1,41 @@
+//go:build unix
+
+package log
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ReopenOnSignal reopens f on receipt of any of sigs, or SIGHUP if none are
+// given, until ctx is done, so that an external tool such as logrotate can
+// move the file aside and signal the process.  HandleLevelSignals also
+// restores logger levels on SIGHUP, so a process handling both should pass
+// another signal if levels must survive a rotation.
+func (f *RotatingFile) ReopenOnSignal(ctx context.Context, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, sigs...)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				if err := f.Reopen(); err != nil {
+					LoggingLogger().Error("Failed to reopen log file",
+						"path", f.opts.Path,
+						ErrorKey, err)
+				}
+			}
+		}
+	}()
+}
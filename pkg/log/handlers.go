@@ -7,6 +7,7 @@ package log
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
@@ -16,7 +17,6 @@ import (
 	"code.internetisalie.net/slogan/pkg/errors"
 
 	"github.com/lmittmann/tint"
-	"github.com/mattn/go-isatty"
 	slogmulti "github.com/samber/slog-multi"
 )
 
@@ -31,30 +31,65 @@ const (
 
 var TerminalFormat string
 
+// NewConsoleHandler returns a console handler configured from the LOG_FORMAT
+// and LOG_OUTPUT environment variables.
 func NewConsoleHandler(ho *slog.HandlerOptions) slog.Handler {
-	format := FormatDefault
-	if isatty.IsTerminal(os.Stdout.Fd()) {
-		format = mo.EmptyableToOption(TerminalFormat).OrElse(FormatTint)
-	}
-
-	if requestedFormat := os.Getenv("LOG_FORMAT"); requestedFormat != "" {
-		requestedFormat = strings.ToLower(requestedFormat)
-
-		switch requestedFormat {
-		case FormatJson, FormatLogFmt, FormatHuman, FormatTint, FormatPlain:
-			format = requestedFormat
-		default:
-			_ = os.Setenv("LOG_FORMAT", format)
-			StandardLogger().Error(fmt.Sprintf(
-				"Unknown log format %q.  Defaulting to %q",
-				requestedFormat, format))
+	return NewConsoleHandlerWithOptions(ho, ConsoleOptionsFromEnv())
+}
+
+// NewConsoleHandlerWithOptions returns a console handler writing to the
+// writers in opts.  Unless a format is requested, each writer uses the
+// terminal format if it is a terminal, and the default format otherwise.
+func NewConsoleHandlerWithOptions(ho *slog.HandlerOptions, opts ConsoleOptions) slog.Handler {
+	writer := mo.EmptyableToOption[io.Writer](opts.Writer).OrElse(os.Stdout)
+	console := newConsoleWriterHandler(writer, opts.Format, ho)
+
+	if opts.ErrorWriter != nil {
+		console = &splitHandler{
+			low:   console,
+			high:  newConsoleWriterHandler(opts.ErrorWriter, opts.Format, ho),
+			level: mo.EmptyableToOption[slog.Leveler](opts.SplitLevel).OrElse(LevelWarn),
 		}
 	}
 
-	consoleWriter := os.Stdout
+	return NewContextLevelHandler(console)
+}
+
+// requestedConsoleFormat returns the format requested by LOG_FORMAT, if any
+func requestedConsoleFormat() string {
+	requestedFormat := os.Getenv("LOG_FORMAT")
+	if requestedFormat == "" {
+		return ""
+	}
+
+	requestedFormat = strings.ToLower(requestedFormat)
+	switch requestedFormat {
+	case FormatJson, FormatLogFmt, FormatHuman, FormatTint, FormatPlain:
+		return requestedFormat
+	default:
+		format := consoleFormat(os.Stdout, "")
+		_ = os.Setenv("LOG_FORMAT", format)
+		StandardLogger().Error(fmt.Sprintf(
+			"Unknown log format %q.  Defaulting to %q",
+			requestedFormat, format))
+		return ""
+	}
+}
+
+// consoleFormat returns the requested format, or the default format for the writer
+func consoleFormat(w io.Writer, requestedFormat string) string {
+	if requestedFormat != "" {
+		return requestedFormat
+	}
+	if isTerminal(w) {
+		return mo.EmptyableToOption(TerminalFormat).OrElse(FormatTint)
+	}
+	return FormatDefault
+}
 
+func newConsoleWriterHandler(consoleWriter io.Writer, requestedFormat string, ho *slog.HandlerOptions) slog.Handler {
 	var console slog.Handler
-	switch format {
+	switch consoleFormat(consoleWriter, requestedFormat) {
 	case FormatJson:
 		console = slog.NewJSONHandler(consoleWriter, ho)
 	case FormatLogFmt:
@@ -65,7 +100,7 @@ func NewConsoleHandler(ho *slog.HandlerOptions) slog.Handler {
 			Level:       ho.Level,
 			ReplaceAttr: ho.ReplaceAttr,
 			TimeFormat:  "15:04:05.000000",
-			NoColor:     !isatty.IsTerminal(consoleWriter.Fd()),
+			NoColor:     !isTerminal(consoleWriter),
 		})
 	case FormatHuman:
 		console = NewHumanHandler(consoleWriter, ho)
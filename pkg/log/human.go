@@ -46,6 +46,9 @@ func NewHumanHandler(out io.Writer, opts *slog.HandlerOptions) *HumanHandler {
 }
 
 func (h *HumanHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if override, ok := levelFromContext(ctx); ok {
+		return level >= override
+	}
 	return level >= h.opts.Level.Level()
 }
 
@@ -0,0 +1,192 @@
+package log
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const journalDefaultSocket = "/run/systemd/journal/socket"
+
+// journalReservedFields are the fields sent by JournalHandler, and those
+// otherwise interpreted by journald
+var journalReservedFields = map[string]bool{
+	"MESSAGE":            true,
+	"MESSAGE_ID":         true,
+	"PRIORITY":           true,
+	"CODE_FILE":          true,
+	"CODE_LINE":          true,
+	"CODE_FUNC":          true,
+	"ERRNO":              true,
+	"INVOCATION_ID":      true,
+	"USER_INVOCATION_ID": true,
+	"SYSLOG_FACILITY":    true,
+	"SYSLOG_IDENTIFIER":  true,
+	"SYSLOG_PID":         true,
+	"SYSLOG_TIMESTAMP":   true,
+	"SYSLOG_RAW":         true,
+	"DOCUMENTATION":      true,
+	"TID":                true,
+}
+
+type JournalOptions struct {
+	// Socket defaults to /run/systemd/journal/socket.
+	Socket string
+	// Identifier is sent as SYSLOG_IDENTIFIER.  Defaults to the executable name.
+	Identifier string
+	// Level defaults to LevelInfo.
+	Level slog.Leveler
+}
+
+// JournalHandler sends records to journald using its native protocol.
+// Attributes become uppercase journal fields named by their group path, so
+// that the attribute "id" in group "request" is sent as REQUEST_ID.  Those
+// named like fields with a meaning to journald, such as "message", are
+// prefixed with X_.
+type JournalHandler struct {
+	opts       JournalOptions
+	attrs      []slog.Attr
+	groups     []string
+	conn       *journalConn
+	unregister func()
+}
+
+func NewJournalHandler(opts JournalOptions) *JournalHandler {
+	if opts.Socket == "" {
+		opts.Socket = journalDefaultSocket
+	}
+	if opts.Identifier == "" {
+		opts.Identifier = filepath.Base(os.Args[0])
+	}
+	if opts.Level == nil {
+		opts.Level = LevelInfo
+	}
+
+	h := &JournalHandler{
+		opts: opts,
+		conn: &journalConn{
+			socket: opts.Socket,
+			mu:     &sync.Mutex{},
+		},
+	}
+	h.unregister = RegisterLifecycle(h)
+	return h
+}
+
+// NewJournalHandlerFactory returns a factory for RegisterRemoteHandlerFactory
+// sharing one journal connection.
+func NewJournalHandlerFactory(opts JournalOptions) RemoteHandlerFactory {
+	h := NewJournalHandler(opts)
+	return func() slog.Handler { return h }
+}
+
+func (h *JournalHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if override, ok := levelFromContext(ctx); ok {
+		return level >= override
+	}
+	return level >= h.opts.Level.Level()
+}
+
+func (h *JournalHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = AddGroup(h.groups, name)
+	return &h2
+}
+
+func (h *JournalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.attrs = SetAttrsAtPath(h.attrs, h.groups, attrs)
+	return &h2
+}
+
+func (h *JournalHandler) Handle(_ context.Context, r slog.Record) error {
+	bufp := allocBuf()
+	buf := *bufp
+	defer func() {
+		*bufp = buf
+		freeBuf(bufp)
+	}()
+
+	buf = appendJournalField(buf, "MESSAGE", r.Message)
+	buf = appendJournalField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(r.Level)))
+	buf = appendJournalField(buf, "SYSLOG_IDENTIFIER", h.opts.Identifier)
+
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		buf = appendJournalField(buf, "CODE_FILE", frame.File)
+		buf = appendJournalField(buf, "CODE_LINE", strconv.Itoa(frame.Line))
+		buf = appendJournalField(buf, "CODE_FUNC", frame.Function)
+	}
+
+	recordAttrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		recordAttrs = append(recordAttrs, a)
+		return true
+	})
+	walkAttrs(nil, SetAttrsAtPath(h.attrs, h.groups, recordAttrs), func(path []string, value slog.Value) {
+		buf = appendJournalField(buf, journalFieldName(path), syslogValueString(value))
+	})
+
+	return h.conn.send(buf)
+}
+
+// Flush does nothing, since records are sent as they are handled.
+func (h *JournalHandler) Flush(context.Context) error {
+	return nil
+}
+
+// Close closes the connection to journald, shared by every handler derived
+// from h.  Records handled afterwards fail.
+func (h *JournalHandler) Close(context.Context) error {
+	h.unregister()
+	return h.conn.close()
+}
+
+// journalFieldName returns a valid journal field name for an attribute
+// path: uppercase letters, digits and underscores, not starting with an
+// underscore or digit, not reserved, and at most 64 characters
+func journalFieldName(path []string) string {
+	name := []byte(strings.ToUpper(strings.Join(path, "_")))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+
+	result := strings.TrimLeft(string(name), "_")
+	if result == "" || result[0] >= '0' && result[0] <= '9' || journalReservedFields[result] {
+		result = "X_" + result
+	}
+	if len(result) > 64 {
+		result = result[:64]
+	}
+	return result
+}
+
+// appendJournalField appends a field in the journal native format, which
+// length-prefixes values containing newlines
+func appendJournalField(buf []byte, name, value string) []byte {
+	buf = append(buf, name...)
+	if strings.IndexByte(value, '\n') < 0 {
+		buf = append(buf, '=')
+		buf = append(buf, value...)
+		return append(buf, '\n')
+	}
+
+	buf = append(buf, '\n')
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(value)))
+	buf = append(buf, value...)
+	return append(buf, '\n')
+}
@@ -0,0 +1,102 @@
+//go:build linux
+
+package log
+
+import (
+	"net"
+	"os"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+var errJournalClosed = errors.NewSentinel("journal handler closed")
+
+// journalConn is a journald socket, reopened after a failed send
+type journalConn struct {
+	socket string
+	mu     *sync.Mutex
+	conn   *net.UnixConn
+	closed bool
+}
+
+func (c *journalConn) send(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errors.WrapSentinel(errJournalClosed, c.socket)
+	}
+
+	if c.conn == nil {
+		// an unconnected, autobound socket, since connected datagram
+		// sockets cannot pass file descriptors to an address
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+		if err != nil {
+			return errors.WrapSentinel(err, "failed to open journal socket")
+		}
+		c.conn = conn
+	}
+
+	_, _, err := c.conn.WriteMsgUnix(data, nil, c.addr())
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		return c.sendMemfdLocked(data)
+	}
+
+	_ = c.conn.Close()
+	c.conn = nil
+	return errors.WrapSentinel(err, "failed to send journal record")
+}
+
+// sendMemfdLocked passes a record too large for a datagram as a sealed memfd
+func (c *journalConn) sendMemfdLocked(data []byte) error {
+	fd, err := unix.MemfdCreate("journal-message", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to create journal memfd")
+	}
+
+	file := os.NewFile(uintptr(fd), "journal-message")
+	defer file.Close()
+
+	if _, err = file.Write(data); err != nil {
+		return errors.WrapSentinel(err, "failed to write journal memfd")
+	}
+
+	const seals = unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+	if _, err = unix.FcntlInt(file.Fd(), unix.F_ADD_SEALS, seals); err != nil {
+		return errors.WrapSentinel(err, "failed to seal journal memfd")
+	}
+
+	_, _, err = c.conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), c.addr())
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to send journal record")
+	}
+	return nil
+}
+
+func (c *journalConn) addr() *net.UnixAddr {
+	return &net.UnixAddr{Name: c.socket, Net: "unixgram"}
+}
+
+func (c *journalConn) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to close journal socket")
+	}
+	return nil
+}
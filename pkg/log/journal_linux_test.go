@@ -0,0 +1,142 @@
+//go:build linux
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseJournalFields decodes the journal native format
+func parseJournalFields(t *testing.T, data []byte) map[string]string {
+	result := make(map[string]string)
+	for len(data) > 0 {
+		line, rest, _ := bytes.Cut(data, []byte("\n"))
+		if name, value, ok := bytes.Cut(line, []byte("=")); ok {
+			result[string(name)] = string(value)
+			data = rest
+			continue
+		}
+		if !assert.GreaterOrEqual(t, len(rest), 8) {
+			break
+		}
+		size := binary.LittleEndian.Uint64(rest)
+		result[string(line)] = string(rest[8 : 8+size])
+		data = rest[8+size+1:]
+	}
+	return result
+}
+
+func listenJournal(t *testing.T) (*net.UnixConn, string) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = server.Close() })
+	_ = server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return server, path
+}
+
+func TestJournalFieldName(t *testing.T) {
+	assert.Equal(t, "REQUEST_ID", journalFieldName([]string{"request", "id"}))
+	assert.Equal(t, "HTTP_STATUS_CODE", journalFieldName([]string{"http.status-code"}))
+	assert.Equal(t, "PRIVATE", journalFieldName([]string{"_private"}))
+	assert.Equal(t, "X_1ST", journalFieldName([]string{"1st"}))
+	assert.Equal(t, "X_MESSAGE", journalFieldName([]string{"message"}))
+	assert.Equal(t, "X_PRIORITY", journalFieldName([]string{"_priority"}))
+	assert.Equal(t, "CODE", journalFieldName([]string{"code"}))
+	assert.Len(t, journalFieldName([]string{strings.Repeat("a", 100)}), 64)
+}
+
+func TestJournalHandler(t *testing.T) {
+	server, path := listenJournal(t)
+
+	h := NewJournalHandler(JournalOptions{Socket: path, Identifier: "app"})
+	defer h.Close(context.Background())
+
+	logger := slog.New(h).With(LoggerKey, "svc.db").WithGroup("query")
+	logger.Warn("slow query", "sql", "select 1\nfrom dual", "ms", 1500)
+	slog.New(h).Info("shadowed", "message", "attr", "priority", 0)
+
+	packet := make([]byte, 4096)
+	n, err := server.Read(packet)
+	assert.NoError(t, err)
+
+	fields := parseJournalFields(t, packet[:n])
+	assert.Equal(t, "slow query", fields["MESSAGE"])
+	assert.Equal(t, "4", fields["PRIORITY"])
+	assert.Equal(t, "app", fields["SYSLOG_IDENTIFIER"])
+	assert.Equal(t, "svc.db", fields["LOGGER"])
+	assert.Equal(t, "select 1\nfrom dual", fields["QUERY_SQL"])
+	assert.Equal(t, "1500", fields["QUERY_MS"])
+	assert.True(t, strings.HasSuffix(fields["CODE_FILE"], "journal_linux_test.go"), fields["CODE_FILE"])
+	assert.NotEmpty(t, fields["CODE_LINE"])
+	assert.Equal(t, "code.internetisalie.net/slogan/pkg/log.TestJournalHandler", fields["CODE_FUNC"])
+
+	n, err = server.Read(packet)
+	assert.NoError(t, err)
+	fields = parseJournalFields(t, packet[:n])
+	assert.Equal(t, "shadowed", fields["MESSAGE"])
+	assert.Equal(t, "6", fields["PRIORITY"])
+	assert.Equal(t, "attr", fields["X_MESSAGE"])
+	assert.Equal(t, "0", fields["X_PRIORITY"])
+}
+
+func TestJournalHandler_Close(t *testing.T) {
+	_, path := listenJournal(t)
+
+	h := NewJournalHandler(JournalOptions{Socket: path})
+	assert.Contains(t, registeredLifecycles(), Lifecycle(h), "registered")
+
+	assert.NoError(t, h.Close(context.Background()))
+	assert.NotContains(t, registeredLifecycles(), Lifecycle(h), "unregistered")
+	err := h.WithGroup("request").Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "dropped", 0))
+	assert.ErrorIs(t, err, errJournalClosed)
+}
+
+func TestJournalHandler_Memfd(t *testing.T) {
+	server, path := listenJournal(t)
+
+	h := NewJournalHandler(JournalOptions{Socket: path})
+	defer h.Close(context.Background())
+
+	message := strings.Repeat("x", 4<<20)
+	slog.New(h).Error(message)
+
+	packet := make([]byte, 4096)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := server.ReadMsgUnix(packet, oob)
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	assert.NoError(t, err)
+	if !assert.Len(t, messages, 1) {
+		return
+	}
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	assert.NoError(t, err)
+	if !assert.Len(t, fds, 1) {
+		return
+	}
+
+	file := os.NewFile(uintptr(fds[0]), "memfd")
+	defer file.Close()
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, 8<<20))
+	assert.NoError(t, err)
+
+	fields := parseJournalFields(t, data)
+	assert.Equal(t, message, fields["MESSAGE"])
+	assert.Equal(t, "3", fields["PRIORITY"])
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package log
+
+import (
+	"sync"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+var errJournalUnsupported = errors.NewSentinel("journald is only supported on linux")
+
+// journalConn fails every send on platforms without journald
+type journalConn struct {
+	socket string
+	mu     *sync.Mutex
+}
+
+func (c *journalConn) send(data []byte) error {
+	return errJournalUnsupported
+}
+
+func (c *journalConn) close() error {
+	return nil
+}
@@ -1,12 +1,19 @@
 package log
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
-	"os"
+	"maps"
+	"path"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/samber/lo"
+
+	"code.internetisalie.net/slogan/pkg/errors"
 )
 
 const (
@@ -17,28 +24,353 @@ const (
 	LevelError = slog.LevelError
 )
 
+// RootLoggerName names the root of the logger hierarchy.  Its level is
+// inherited by every logger without a configured ancestor.
+const RootLoggerName = ""
+
+// errFatal causes the exit of Fatal calls logging no error value
+var errFatal = errors.NewSentinel("fatal")
+
 var (
-	loggerLevels     = make(map[string]*slog.LevelVar)
+	loggerLevels     = make(map[string]*slog.LevelVar) // effective levels of registered loggers
+	loggerConfigured = make(map[string]slog.Level)     // explicitly configured levels
+	loggerPatterns   []loggerPattern                   // configured levels by glob pattern
+	loggerExpiries   = make(map[string]*loggerExpiry)  // pending reverts of configured levels
+	loggerElevations []*loggerElevation                // active temporary elevations
 	loggerLevelsLock sync.Mutex
 )
 
+// Elevation temporarily raises the verbosity of a logger and its descendants.
+type Elevation struct {
+	Name    string
+	Level   slog.Level
+	Expires time.Time
+}
+
+type loggerElevation struct {
+	Elevation
+	timer *time.Timer
+}
+
+// loggerExpiry restores a logger's previous configuration when a temporary level expires
+type loggerExpiry struct {
+	expires  time.Time
+	timer    *time.Timer
+	previous slog.Level
+	existed  bool
+}
+
+type loggerPattern struct {
+	pattern string
+	level   slog.Level
+}
+
+// parentLoggerName returns the parent of a dotted logger name, such as
+// `svc.db` for `svc.db.pool`.  Top-level loggers are children of the root.
+func parentLoggerName(name string) (string, bool) {
+	if name == RootLoggerName {
+		return "", false
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i], true
+	}
+	return RootLoggerName, true
+}
+
+// patternLevelLocked returns the level of the last configured pattern matching the logger
+func patternLevelLocked(name string) (slog.Level, bool) {
+	for i := len(loggerPatterns) - 1; i >= 0; i-- {
+		if ok, _ := path.Match(loggerPatterns[i].pattern, name); ok {
+			return loggerPatterns[i].level, true
+		}
+	}
+	return LevelInfo, false
+}
+
+// configuredLevelLocked returns the level configured for the logger or its
+// nearest configured ancestor.  Exact names take precedence over patterns.
+func configuredLevelLocked(name string) (slog.Level, bool) {
+	for {
+		if level, ok := loggerConfigured[name]; ok {
+			return level, true
+		}
+		if level, ok := patternLevelLocked(name); ok {
+			return level, true
+		}
+		parent, ok := parentLoggerName(name)
+		if !ok {
+			return LevelInfo, false
+		}
+		name = parent
+	}
+}
+
+// isLoggerDescendant reports whether name is the ancestor logger or one of its descendants
+func isLoggerDescendant(name, ancestor string) bool {
+	return ancestor == RootLoggerName || name == ancestor || strings.HasPrefix(name, ancestor+".")
+}
+
+// effectiveLevelLocked returns the configured level of the logger, lowered by
+// any active elevation of the logger or its ancestors
+func effectiveLevelLocked(name string) slog.Level {
+	level, _ := configuredLevelLocked(name)
+	for _, elevation := range loggerElevations {
+		if elevation.Level < level && isLoggerDescendant(name, elevation.Name) {
+			level = elevation.Level
+		}
+	}
+	return level
+}
+
+// updateLoggerLevelsLocked propagates configuration changes to every registered logger
+func updateLoggerLevelsLocked() {
+	for name, levelVar := range loggerLevels {
+		levelVar.Set(effectiveLevelLocked(name))
+	}
+}
+
+// SetAllLoggerLevels discards all configured logger levels and patterns, and
+// sets the level of the root logger, inherited by every logger.
 func SetAllLoggerLevels(level slog.Level) {
-	names := func() []string {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	clear(loggerConfigured)
+	loggerPatterns = nil
+	cancelLoggerExpiriesLocked()
+	loggerConfigured[RootLoggerName] = level
+	updateLoggerLevelsLocked()
+}
+
+// stepLoggerLevels moves the root level, and every configured level and
+// pattern, the given number of signal levels, where negative steps are more
+// verbose.  Pending reverts of temporary levels are stepped alike.
+func stepLoggerLevels(steps int) {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	if _, ok := loggerConfigured[RootLoggerName]; !ok {
+		loggerConfigured[RootLoggerName] = LevelInfo
+	}
+	for name, level := range loggerConfigured {
+		loggerConfigured[name] = stepLevel(level, steps)
+	}
+	for i := range loggerPatterns {
+		loggerPatterns[i].level = stepLevel(loggerPatterns[i].level, steps)
+	}
+	for _, expiry := range loggerExpiries {
+		expiry.previous = stepLevel(expiry.previous, steps)
+	}
+	updateLoggerLevelsLocked()
+}
+
+type loggerLevelsSnapshot struct {
+	configured map[string]slog.Level
+	patterns   []loggerPattern
+}
+
+// snapshotLoggerLevels captures the configured levels and patterns
+func snapshotLoggerLevels() loggerLevelsSnapshot {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	return loggerLevelsSnapshot{
+		configured: maps.Clone(loggerConfigured),
+		patterns:   slices.Clone(loggerPatterns),
+	}
+}
+
+// restoreLoggerLevels replaces the configured levels and patterns with a snapshot
+func restoreLoggerLevels(snapshot loggerLevelsSnapshot) {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	cancelLoggerExpiriesLocked()
+	loggerConfigured = maps.Clone(snapshot.configured)
+	loggerPatterns = slices.Clone(snapshot.patterns)
+	updateLoggerLevelsLocked()
+}
+
+// SetLoggerLevel configures the level of the named logger, and of every
+// descendant without a closer configured ancestor.
+func SetLoggerLevel(name string, level slog.Level) {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	cancelLoggerExpiryLocked(name)
+	loggerConfigured[name] = level
+	updateLoggerLevelsLocked()
+}
+
+// SetLoggerLevelFor configures the level of the named logger, reverting to
+// its previous configuration after ttl.
+func SetLoggerLevelFor(name string, level slog.Level, ttl time.Duration) {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	expiry := loggerExpiries[name]
+	if expiry != nil {
+		// keep the configuration from before the first temporary level
+		expiry.timer.Stop()
+	} else {
+		expiry = new(loggerExpiry)
+		expiry.previous, expiry.existed = loggerConfigured[name]
+		loggerExpiries[name] = expiry
+	}
+
+	expiry.expires = time.Now().Add(ttl)
+	expiry.timer = time.AfterFunc(ttl, func() {
 		loggerLevelsLock.Lock()
 		defer loggerLevelsLock.Unlock()
 
-		return lo.Keys(loggerLevels)
-	}()
+		if loggerExpiries[name] != expiry {
+			return
+		}
+		delete(loggerExpiries, name)
 
-	for _, name := range names {
-		SetLoggerLevel(name, level)
+		if expiry.existed {
+			loggerConfigured[name] = expiry.previous
+		} else {
+			delete(loggerConfigured, name)
+		}
+		updateLoggerLevelsLocked()
+	})
+
+	loggerConfigured[name] = level
+	updateLoggerLevelsLocked()
+}
+
+func cancelLoggerExpiryLocked(name string) {
+	if expiry, ok := loggerExpiries[name]; ok {
+		expiry.timer.Stop()
+		delete(loggerExpiries, name)
 	}
 }
 
-func SetLoggerLevel(name string, level slog.Level) {
-	GetLoggerLeveler(name).Set(level)
+func cancelLoggerExpiriesLocked() {
+	for name := range loggerExpiries {
+		cancelLoggerExpiryLocked(name)
+	}
+}
+
+// SetLoggerPatternLevel configures the level of every logger whose name
+// matches the glob pattern, such as `svc.http.*`, including loggers created
+// later.  Later patterns take precedence over earlier ones.
+func SetLoggerPatternLevel(pattern string, level slog.Level) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errors.WrapSentinel(err, fmt.Sprintf("invalid logger pattern %q", pattern))
+	}
+
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	loggerPatterns = append(loggerPatterns, loggerPattern{
+		pattern: pattern,
+		level:   level,
+	})
+	updateLoggerLevelsLocked()
+	return nil
+}
+
+// ClearLoggerLevel removes the configured level of the named logger, so that
+// it inherits the level of its nearest configured ancestor.
+func ClearLoggerLevel(name string) {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	cancelLoggerExpiryLocked(name)
+	delete(loggerConfigured, name)
+	updateLoggerLevelsLocked()
+}
+
+// ElevateLevel lowers the level of the named logger and its descendants to at
+// most level for the given duration, after which the previous levels are
+// restored.  Overlapping elevations stack: each logger follows the most
+// verbose active elevation.  The returned function ends the elevation early.
+func ElevateLevel(name string, level slog.Level, duration time.Duration) (cancel func()) {
+	elevation := &loggerElevation{
+		Elevation: Elevation{
+			Name:    name,
+			Level:   level,
+			Expires: time.Now().Add(duration),
+		},
+	}
+
+	cancel = func() {
+		loggerLevelsLock.Lock()
+		defer loggerLevelsLock.Unlock()
+
+		elevation.timer.Stop()
+		index := slices.Index(loggerElevations, elevation)
+		if index < 0 {
+			return
+		}
+		loggerElevations = slices.Delete(loggerElevations, index, index+1)
+		updateLoggerLevelsLocked()
+	}
+
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	elevation.timer = time.AfterFunc(duration, cancel)
+	loggerElevations = append(loggerElevations, elevation)
+	updateLoggerLevelsLocked()
+
+	return cancel
+}
+
+// ActiveElevations returns the elevations in effect, in the order they were started.
+func ActiveElevations() []Elevation {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	return lo.Map(loggerElevations, func(item *loggerElevation, _ int) Elevation {
+		return item.Elevation
+	})
+}
+
+type LoggerLevelStatus struct {
+	Name       string
+	Level      slog.Level // effective level
+	Configured bool       // whether the logger itself has a configured level
+	Expires    time.Time  // when a temporary configured level reverts, if any
+}
+
+// LoggerLevels returns the status of every registered or configured logger, ordered by name.
+func LoggerLevels() []LoggerLevelStatus {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	names := lo.Uniq(append(lo.Keys(loggerLevels), lo.Keys(loggerConfigured)...))
+	slices.Sort(names)
+
+	result := make([]LoggerLevelStatus, len(names))
+	for i, name := range names {
+		_, configured := loggerConfigured[name]
+		result[i] = LoggerLevelStatus{
+			Name:       name,
+			Level:      effectiveLevelLocked(name),
+			Configured: configured,
+		}
+		if expiry, ok := loggerExpiries[name]; ok {
+			result[i].Expires = expiry.expires
+		}
+	}
+	return result
 }
 
+// GetLoggerLevel returns the configured level of the named logger, and
+// whether it or an ancestor has one.  Active elevations are ignored; the
+// leveler returned by GetLoggerLeveler carries the effective level.
+func GetLoggerLevel(name string) (slog.Level, bool) {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	return configuredLevelLocked(name)
+}
+
+// GetLoggerLeveler returns the level of the named logger, which is updated
+// whenever the configuration of the logger or its ancestors changes.
 func GetLoggerLeveler(name string) *slog.LevelVar {
 	loggerLevelsLock.Lock()
 	defer loggerLevelsLock.Unlock()
@@ -46,6 +378,7 @@ func GetLoggerLeveler(name string) *slog.LevelVar {
 	existingLevel, ok := loggerLevels[name]
 	if !ok {
 		existingLevel = new(slog.LevelVar)
+		existingLevel.Set(effectiveLevelLocked(name))
 		loggerLevels[name] = existingLevel
 	}
 
@@ -96,19 +429,37 @@ func (l *LevelLogger) Println(values ...interface{}) {
 func (l *LevelLogger) Fatal(values ...interface{}) {
 	msg := fmt.Sprint(values...)
 	l.parent.Log(nil, LevelError, msg)
-	os.Exit(1)
+	l.exit(msg, values)
 }
 
 func (l *LevelLogger) Fatalf(template string, values ...interface{}) {
 	msg := fmt.Sprintf(template, values...)
 	l.parent.Log(nil, LevelError, msg)
-	os.Exit(1)
+	l.exit(msg, values)
 }
 
 func (l *LevelLogger) Fatalln(values ...interface{}) {
 	msg := fmt.Sprint(values...)
 	l.parent.Log(nil, LevelError, msg)
-	os.Exit(1)
+	l.exit(msg, values)
+}
+
+// exit drains every registered lifecycle, then terminates the process with
+// the exit code of the first error in values
+func (l *LevelLogger) exit(msg string, values []interface{}) {
+	err := errors.WrapSentinel(errFatal, msg)
+	for _, value := range values {
+		if e, ok := value.(error); ok {
+			err = e
+			break
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	_ = Shutdown(ctx)
+	cancel()
+
+	errors.ExitFunc(errors.ExitCode(err))
 }
 
 func (l *LevelLogger) Panic(values ...interface{}) {
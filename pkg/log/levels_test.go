@@ -0,0 +1,134 @@
+package log
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func resetLoggerLevels(t *testing.T) {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	clear(loggerLevels)
+	clear(loggerConfigured)
+	loggerPatterns = nil
+	cancelLoggerExpiriesLocked()
+	loggerElevations = nil
+
+	t.Cleanup(func() {
+		loggerLevelsLock.Lock()
+		defer loggerLevelsLock.Unlock()
+
+		clear(loggerLevels)
+		clear(loggerConfigured)
+		loggerPatterns = nil
+		cancelLoggerExpiriesLocked()
+		loggerElevations = nil
+	})
+}
+
+func TestSetLoggerLevel_Hierarchy(t *testing.T) {
+	resetLoggerLevels(t)
+
+	pool := GetLoggerLeveler("svc.db.pool")
+	db := GetLoggerLeveler("svc.db")
+	http := GetLoggerLeveler("svc.http")
+	assert.Equal(t, LevelInfo, pool.Level())
+
+	SetLoggerLevel("svc.db", LevelDebug)
+	assert.Equal(t, LevelDebug, db.Level())
+	assert.Equal(t, LevelDebug, pool.Level())
+	assert.Equal(t, LevelInfo, http.Level())
+
+	SetLoggerLevel("svc", LevelWarn)
+	assert.Equal(t, LevelDebug, pool.Level())
+	assert.Equal(t, LevelWarn, http.Level())
+
+	// created after configuration
+	assert.Equal(t, LevelDebug, GetLoggerLeveler("svc.db.pool.conn").Level())
+
+	ClearLoggerLevel("svc.db")
+	assert.Equal(t, LevelWarn, pool.Level())
+
+	SetAllLoggerLevels(LevelError)
+	for _, levelVar := range []*slog.LevelVar{pool, db, http} {
+		assert.Equal(t, LevelError, levelVar.Level())
+	}
+	assert.Equal(t, LevelError, GetLoggerLeveler("other").Level())
+}
+
+func TestApplyLevelSpec(t *testing.T) {
+	resetLoggerLevels(t)
+
+	server := GetLoggerLeveler("svc.http.server")
+	db := GetLoggerLeveler("svc.db.pool")
+
+	err := ApplyLevelSpec("warn, svc.db=debug, svc.http.*=trace, glimmer=error")
+	assert.NoError(t, err)
+
+	assert.Equal(t, LevelTrace, server.Level())
+	assert.Equal(t, LevelDebug, db.Level())
+	assert.Equal(t, LevelWarn, GetLoggerLeveler("svc.http").Level())
+	assert.Equal(t, LevelTrace, GetLoggerLeveler("svc.http.client").Level())
+	assert.Equal(t, LevelError, GetLoggerLeveler("glimmer.core").Level())
+	assert.Equal(t, LevelWarn, GetLoggerLeveler("other").Level())
+
+	assert.Error(t, ApplyLevelSpec("svc=loud"))
+	assert.Error(t, ApplyLevelSpec("svc.[=debug"))
+	assert.Equal(t, LevelDebug, db.Level())
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, level := range []slog.Level{LevelTrace, LevelTrace + 1, LevelDebug, LevelInfo, LevelWarn + 2, LevelError} {
+		parsed, err := ParseLevel(LevelName(level))
+		assert.NoError(t, err)
+		assert.Equal(t, level, parsed)
+	}
+
+	parsed, err := ParseLevel("warning")
+	assert.NoError(t, err)
+	assert.Equal(t, LevelWarn, parsed)
+}
+
+func TestElevateLevel(t *testing.T) {
+	resetLoggerLevels(t)
+
+	SetLoggerLevel("svc", LevelWarn)
+	svc := GetLoggerLeveler("svc")
+	pool := GetLoggerLeveler("svc.db.pool")
+	http := GetLoggerLeveler("svc.http")
+
+	cancelDebug := ElevateLevel("svc", LevelDebug, time.Hour)
+	assert.Equal(t, LevelDebug, svc.Level())
+	assert.Equal(t, LevelDebug, pool.Level())
+	level, configured := GetLoggerLevel("svc.db.pool")
+	assert.Equal(t, LevelWarn, level, "configured level ignores elevations")
+	assert.True(t, configured)
+
+	ElevateLevel("svc.db", LevelTrace, 50*time.Millisecond)
+	assert.Equal(t, LevelTrace, pool.Level())
+	assert.Equal(t, LevelDebug, http.Level())
+	assert.Len(t, ActiveElevations(), 2)
+
+	// the shorter, more verbose elevation expires first
+	assert.Eventually(t, func() bool {
+		return pool.Level() == LevelDebug
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"svc"}, lo.Map(ActiveElevations(), func(item Elevation, _ int) string {
+		return item.Name
+	}))
+
+	// a less verbose elevation has no effect
+	cancelError := ElevateLevel("svc", LevelError, time.Hour)
+	assert.Equal(t, LevelDebug, svc.Level())
+
+	cancelDebug()
+	cancelError()
+	assert.Equal(t, LevelWarn, svc.Level())
+	assert.Equal(t, LevelWarn, pool.Level())
+	assert.Empty(t, ActiveElevations())
+}
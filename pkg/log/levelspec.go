@@ -0,0 +1,109 @@
+package log
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+const levelNameTrace = "TRACE"
+
+// ParseLevel parses a level name such as `trace`, `DEBUG`, `warn` or `INFO+2`.
+func ParseLevel(name string) (slog.Level, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+
+	if strings.HasPrefix(name, levelNameTrace) {
+		offset := 0
+		if suffix := strings.TrimPrefix(name, levelNameTrace); suffix != "" {
+			var err error
+			if offset, err = strconv.Atoi(suffix); err != nil {
+				return LevelInfo, errors.WrapSentinel(err, fmt.Sprintf("invalid level %q", name))
+			}
+		}
+		return LevelTrace + slog.Level(offset), nil
+	}
+
+	if name == "WARNING" {
+		name = "WARN"
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return LevelInfo, errors.WrapSentinel(err, fmt.Sprintf("invalid level %q", name))
+	}
+	return level, nil
+}
+
+// LevelName returns the name of a level, as accepted by ParseLevel.
+func LevelName(level slog.Level) string {
+	if level < LevelDebug {
+		if level == LevelTrace {
+			return levelNameTrace
+		}
+		return fmt.Sprintf("%s%+d", levelNameTrace, level-LevelTrace)
+	}
+	return level.String()
+}
+
+type levelSpecEntry struct {
+	name  string
+	level slog.Level
+}
+
+// ApplyLevelSpec configures logger levels from a comma-separated spec such as
+// `info,svc.db=debug,svc.http.*=trace,glimmer=warn`.  A bare level applies to
+// the root logger, names apply to the logger and its descendants, and glob
+// patterns apply to every matching logger.  The spec is validated before any
+// level is changed.
+func ApplyLevelSpec(spec string) error {
+	var entries []levelSpecEntry
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, levelName, found := strings.Cut(item, "=")
+		if !found {
+			name, levelName = RootLoggerName, item
+		}
+
+		level, err := ParseLevel(levelName)
+		if err != nil {
+			return err
+		}
+
+		name = strings.TrimSpace(name)
+		if _, err = path.Match(name, ""); err != nil {
+			return errors.WrapSentinel(err, fmt.Sprintf("invalid logger pattern %q", name))
+		}
+
+		entries = append(entries, levelSpecEntry{
+			name:  name,
+			level: level,
+		})
+	}
+
+	for _, entry := range entries {
+		if strings.ContainsAny(entry.name, "*?[") {
+			_ = SetLoggerPatternLevel(entry.name, entry.level)
+		} else {
+			SetLoggerLevel(entry.name, entry.level)
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	if spec := os.Getenv("LOG_LEVEL"); spec != "" {
+		if err := ApplyLevelSpec(spec); err != nil {
+			LoggingLogger().Error(fmt.Sprintf("Invalid LOG_LEVEL %q: %v", spec, err))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+// Lifecycle is implemented by handlers and writers that buffer records or
+// hold resources, so that Shutdown can drain them.
+type Lifecycle interface {
+	// Flush delivers buffered records, waiting until ctx is done.
+	Flush(ctx context.Context) error
+	// Close flushes and releases resources, waiting until ctx is done.
+	Close(ctx context.Context) error
+}
+
+// LifecycleFuncs adapts functions to Lifecycle.  Either may be nil.
+type LifecycleFuncs struct {
+	FlushFunc func(ctx context.Context) error
+	CloseFunc func(ctx context.Context) error
+}
+
+func (l LifecycleFuncs) Flush(ctx context.Context) error {
+	if l.FlushFunc == nil {
+		return nil
+	}
+	return l.FlushFunc(ctx)
+}
+
+func (l LifecycleFuncs) Close(ctx context.Context) error {
+	if l.CloseFunc == nil {
+		return nil
+	}
+	return l.CloseFunc(ctx)
+}
+
+// ShutdownTimeout bounds the Shutdown performed by LevelLogger.Fatal.
+var ShutdownTimeout = 5 * time.Second
+
+type lifecycleEntry struct {
+	lifecycle Lifecycle
+}
+
+var (
+	lifecycles     []*lifecycleEntry
+	lifecyclesLock sync.Mutex
+)
+
+// RegisterLifecycle adds l to those flushed by Flush and closed by Shutdown.
+func RegisterLifecycle(l Lifecycle) (unregister func()) {
+	entry := &lifecycleEntry{lifecycle: l}
+
+	lifecyclesLock.Lock()
+	lifecycles = append(lifecycles, entry)
+	lifecyclesLock.Unlock()
+
+	return func() {
+		lifecyclesLock.Lock()
+		defer lifecyclesLock.Unlock()
+		for i, registered := range lifecycles {
+			if registered == entry {
+				lifecycles = append(lifecycles[:i:i], lifecycles[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// registeredLifecycles returns the registered lifecycles, most recent first,
+// so that wrappers are drained before the handlers they wrap
+func registeredLifecycles() []Lifecycle {
+	lifecyclesLock.Lock()
+	defer lifecyclesLock.Unlock()
+
+	result := make([]Lifecycle, len(lifecycles))
+	for i, entry := range lifecycles {
+		result[len(result)-1-i] = entry.lifecycle
+	}
+	return result
+}
+
+// Flush flushes every registered lifecycle until ctx is done.
+func Flush(ctx context.Context) error {
+	var errs []error
+	for _, l := range registeredLifecycles() {
+		if err := l.Flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown closes every registered lifecycle, most recently registered
+// first, until ctx is done.  Lifecycles are unregistered once closed.
+func Shutdown(ctx context.Context) error {
+	registered := registeredLifecycles()
+
+	lifecyclesLock.Lock()
+	lifecycles = nil
+	lifecyclesLock.Unlock()
+
+	var errs []error
+	for _, l := range registered {
+		if err := l.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
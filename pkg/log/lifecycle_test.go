@@ -0,0 +1,102 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+var errLifecycleClose = errors.NewSentinel("close failed")
+
+// isolateLifecycles hides the lifecycles registered by other tests until the
+// test ends, so that its Flush and Shutdown drain only its own
+func isolateLifecycles(t *testing.T) {
+	lifecyclesLock.Lock()
+	defer lifecyclesLock.Unlock()
+
+	saved := lifecycles
+	lifecycles = nil
+
+	t.Cleanup(func() {
+		lifecyclesLock.Lock()
+		defer lifecyclesLock.Unlock()
+
+		lifecycles = append(saved, lifecycles...)
+	})
+}
+
+func TestShutdown(t *testing.T) {
+	isolateLifecycles(t)
+
+	var events []string
+	lifecycle := func(name string, closeErr error) Lifecycle {
+		return LifecycleFuncs{
+			FlushFunc: func(context.Context) error {
+				events = append(events, "flush "+name)
+				return nil
+			},
+			CloseFunc: func(context.Context) error {
+				events = append(events, "close "+name)
+				return closeErr
+			},
+		}
+	}
+
+	RegisterLifecycle(lifecycle("handler", errLifecycleClose))
+	unregister := RegisterLifecycle(lifecycle("removed", nil))
+	RegisterLifecycle(lifecycle("wrapper", nil))
+	unregister()
+
+	assert.NoError(t, Flush(context.Background()))
+	assert.ErrorIs(t, Shutdown(context.Background()), errLifecycleClose)
+	assert.NoError(t, Shutdown(context.Background()))
+
+	assert.Equal(t, []string{
+		"flush wrapper",
+		"flush handler",
+		"close wrapper",
+		"close handler",
+	}, events)
+}
+
+func TestLevelLogger_FatalShutdown(t *testing.T) {
+	isolateLifecycles(t)
+
+	defer func(exitFunc func(int)) { errors.ExitFunc = exitFunc }(errors.ExitFunc)
+
+	buffer := new(bytes.Buffer)
+	h := NewAsyncHandler(slog.NewTextHandler(buffer, nil), nil)
+	logger := NewLevelLogger(&formattingLogger{logger: slog.New(h)}, LevelInfo)
+
+	var exited int
+	var logged string
+	errors.ExitFunc = func(code int) {
+		exited = code
+		logged = buffer.String()
+	}
+
+	logger.Fatal("giving up")
+
+	assert.Equal(t, errors.ExitFailure, exited)
+	assert.Contains(t, logged, "msg=\"giving up\"", "drained before exit")
+}
+
+func TestNewGoLogger_Shutdown(t *testing.T) {
+	isolateLifecycles(t)
+
+	buffer := new(bytes.Buffer)
+	logger := NewGoLogger(&formattingLogger{logger: slog.New(slog.NewTextHandler(buffer, nil))}, LevelInfo)
+
+	logger.Print("from the standard library")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, Shutdown(ctx))
+	assert.Contains(t, buffer.String(), "msg=\"from the standard library\"")
+}
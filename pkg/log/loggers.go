@@ -6,6 +6,7 @@ package log
 
 import (
 	"bufio"
+	"context"
 	"io"
 	"log"
 	"log/slog"
@@ -95,13 +96,15 @@ func NewLogger(name string, attrs ...slog.Attr) *slog.Logger {
 
 	console := NewConsoleHandler(&ho)
 	remote := NewRemoteHandler()
+	file := NewFileHandler(&ho)
 
 	// inject our handler middleware
 	handler := slogmulti.
-		Pipe(NewErrorAttrsMiddleware()).
+		Pipe(NewSamplingMiddleware(), NewErrorAttrsMiddleware()).
 		Handler(slogmulti.Fanout(
 			console,
 			remote,
+			file,
 		))
 
 	logger := slog.New(handler)
@@ -124,19 +127,33 @@ type goLogWriter struct {
 	*io.PipeWriter
 	scanner *bufio.Scanner
 	logger  StdLogger
+	pumped  chan struct{}
 }
 
 func (g goLogWriter) Pump() {
+	defer close(g.pumped)
 	for g.scanner.Scan() {
 		g.logger.Print(g.scanner.Text())
 	}
 }
 
+// close stops the pump once it has logged every line written
+func (g goLogWriter) close(ctx context.Context) error {
+	_ = g.PipeWriter.Close()
+	select {
+	case <-g.pumped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func NewGoLogger(parent FormattingLogger, level slog.Level) *log.Logger {
 	r, w := io.Pipe()
 	s := bufio.NewScanner(r)
 	logger := NewLevelLogger(parent, level)
-	out := goLogWriter{PipeWriter: w, scanner: s, logger: logger}
+	out := goLogWriter{PipeWriter: w, scanner: s, logger: logger, pumped: make(chan struct{})}
 	go out.Pump()
+	RegisterLifecycle(LifecycleFuncs{CloseFunc: out.close})
 	return log.New(out, "", 0)
 }
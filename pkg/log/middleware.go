@@ -0,0 +1,104 @@
+package log
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderLogLevel carries a signed per-request level override, as produced by SignLevelOverride.
+const HeaderLogLevel = "X-Log-Level"
+
+// MinLevelOverrideKeySize is the minimum size of the key accepted by
+// NewLevelOverrideMiddleware, that of the HMAC-SHA256 output.
+const MinLevelOverrideKeySize = sha256.Size
+
+func levelOverrideSignature(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignLevelOverride returns an X-Log-Level header value requesting the level
+// until expires, signed with key.
+func SignLevelOverride(key []byte, level slog.Level, expires time.Time) string {
+	payload := LevelName(level) + ":" + strconv.FormatInt(expires.Unix(), 10)
+	return payload + ":" + levelOverrideSignature(key, payload)
+}
+
+// VerifyLevelOverride returns the level requested by a signed X-Log-Level
+// header value, if its signature is valid and it has not expired.
+func VerifyLevelOverride(key []byte, value string, now time.Time) (slog.Level, bool) {
+	index := strings.LastIndex(value, ":")
+	if index < 0 {
+		return LevelInfo, false
+	}
+
+	payload, signature := value[:index], value[index+1:]
+	if !hmac.Equal([]byte(signature), []byte(levelOverrideSignature(key, payload))) {
+		return LevelInfo, false
+	}
+
+	levelName, expiresText, found := strings.Cut(payload, ":")
+	if !found {
+		return LevelInfo, false
+	}
+
+	expires, err := strconv.ParseInt(expiresText, 10, 64)
+	if err != nil || now.After(time.Unix(expires, 0)) {
+		return LevelInfo, false
+	}
+
+	level, err := ParseLevel(levelName)
+	if err != nil {
+		return LevelInfo, false
+	}
+
+	return level, true
+}
+
+// NewLevelOverrideMiddleware returns HTTP middleware applying the level
+// requested by a valid signed X-Log-Level header to the request context.
+// Requests with missing, invalid or expired headers are served unchanged.
+// It panics if key is shorter than MinLevelOverrideKeySize, since a short
+// key would let clients forge overrides.
+func NewLevelOverrideMiddleware(key []byte) func(http.Handler) http.Handler {
+	if len(key) < MinLevelOverrideKeySize {
+		panic(fmt.Sprintf("log: level override key must be at least %d bytes, got %d",
+			MinLevelOverrideKeySize, len(key)))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if value := r.Header.Get(HeaderLogLevel); value != "" {
+				if level, ok := VerifyLevelOverride(key, value, time.Now()); ok {
+					r = r.WithContext(ContextWithLevel(r.Context(), level))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// NewTraceparentMiddleware returns HTTP middleware attaching the span context
+// of a valid W3C traceparent header to the request context, so that records
+// logged with it carry trace_id, span_id and trace_flags.  Requests with
+// missing or invalid headers are served unchanged.
+func NewTraceparentMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if value := r.Header.Get(HeaderTraceparent); value != "" {
+				if span, err := ParseTraceparent(value); err == nil {
+					r = r.WithContext(ContextWithSpanContext(r.Context(), span))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelOverrideMiddleware(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), MinLevelOverrideKeySize)
+	output := new(bytes.Buffer)
+	logger := &formattingLogger{
+		logger: slog.New(NewPlainHandler(output, &slog.HandlerOptions{Level: LevelInfo})),
+	}
+
+	handler := NewLevelOverrideMiddleware(key)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.TraceContext(r.Context(), "traced")
+		logger.Debug("not traced")
+	}))
+
+	for _, header := range []string{
+		"",
+		SignLevelOverride([]byte("wrong"), LevelTrace, time.Now().Add(time.Minute)),
+		SignLevelOverride(key, LevelTrace, time.Now().Add(-time.Minute)),
+		SignLevelOverride(key, LevelTrace, time.Now().Add(time.Minute)),
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderLogLevel, header)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, "traced\n", output.String())
+}
+
+func TestLevelOverrideMiddleware_ShortKey(t *testing.T) {
+	assert.PanicsWithValue(t, "log: level override key must be at least 32 bytes, got 0", func() {
+		NewLevelOverrideMiddleware(nil)
+	})
+	assert.PanicsWithValue(t, "log: level override key must be at least 32 bytes, got 6", func() {
+		NewLevelOverrideMiddleware([]byte("secret"))
+	})
+}
+
+func TestTraceparentMiddleware(t *testing.T) {
+	var spans []SpanContext
+	handler := NewTraceparentMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span, _ := SpanContextFromContext(r.Context())
+		spans = append(spans, span)
+	}))
+
+	for _, header := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderTraceparent, header)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, []string{
+		SpanContext{}.String(),
+		SpanContext{}.String(),
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}, lo.Map(spans, func(span SpanContext, _ int) string { return span.String() }))
+}
@@ -0,0 +1,411 @@
+package log
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+const otlpDefaultURL = "http://localhost:4318/v1/logs"
+
+type OTLPOptions struct {
+	// HTTPShipperOptions configure delivery.  URL defaults to the OTLP/HTTP
+	// logs endpoint of a local collector, http://localhost:4318/v1/logs.
+	HTTPShipperOptions
+	// ServiceName is sent as the service.name resource attribute.  Defaults
+	// to the executable name.
+	ServiceName string
+	// Resource attributes describe the process sending the records.
+	Resource []slog.Attr
+}
+
+// OTLPExporter sends records to an OpenTelemetry collector as OTLP/HTTP
+// JSON ExportLogsServiceRequest batches.  The LoggerKey attribute names the
+// instrumentation scope, and errors are sent as exception.* attributes.
+type OTLPExporter struct {
+	shipper  *HTTPShipper
+	resource []otlpKeyValue
+}
+
+func NewOTLPExporter(opts OTLPOptions) (*OTLPExporter, error) {
+	if opts.URL == "" {
+		opts.URL = otlpDefaultURL
+	}
+	if opts.ServiceName == "" {
+		opts.ServiceName = filepath.Base(os.Args[0])
+	}
+
+	s, err := newHTTPShipper(opts.HTTPShipperOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := append([]slog.Attr{slog.String("service.name", opts.ServiceName)}, opts.Resource...)
+	e := &OTLPExporter{
+		shipper:  s,
+		resource: otlpKeyValues(resource),
+	}
+	s.contentType = "application/json"
+	s.encodeBatch = e.encodeBatch
+	s.start()
+	return e, nil
+}
+
+// NewOTLPExporterFactory returns a factory for RegisterRemoteHandlerFactory
+// sharing one exporter.
+func NewOTLPExporterFactory(opts OTLPOptions) (RemoteHandlerFactory, error) {
+	e, err := NewOTLPExporter(opts)
+	if err != nil {
+		return nil, err
+	}
+	handler := e.Handler()
+	return func() slog.Handler { return handler }, nil
+}
+
+// Handler returns a handler encoding records for the exporter.
+func (e *OTLPExporter) Handler() slog.Handler {
+	return &OTLPHandler{exporter: e, level: e.shipper.opts.Level}
+}
+
+// Dropped returns the number of records dropped by the exporter.
+func (e *OTLPExporter) Dropped() uint64 {
+	return e.shipper.Dropped()
+}
+
+// Flush sends queued records, waiting until ctx is done.
+func (e *OTLPExporter) Flush(ctx context.Context) error {
+	return e.shipper.Flush(ctx)
+}
+
+// Close sends queued records and stops the exporter.
+func (e *OTLPExporter) Close(ctx context.Context) error {
+	return e.shipper.Close(ctx)
+}
+
+// OTLPHandler encodes records as OTLP log records for an OTLPExporter.
+type OTLPHandler struct {
+	exporter *OTLPExporter
+	level    slog.Leveler
+	attrs    []slog.Attr
+	groups   []string
+}
+
+func (h *OTLPHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if override, ok := levelFromContext(ctx); ok {
+		return level >= override
+	}
+	return level >= h.level.Level()
+}
+
+func (h *OTLPHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = AddGroup(h.groups, name)
+	return &h2
+}
+
+func (h *OTLPHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.attrs = SetAttrsAtPath(h.attrs, h.groups, attrs)
+	return &h2
+}
+
+func (h *OTLPHandler) Handle(ctx context.Context, r slog.Record) error {
+	record := otlpLogRecord{
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       otlpSeverity(r.Level),
+		SeverityText:         LevelName(r.Level),
+		Body:                 otlpValue(slog.StringValue(r.Message)),
+	}
+	if !r.Time.IsZero() {
+		record.TimeUnixNano = strconv.FormatInt(r.Time.UnixNano(), 10)
+	}
+
+	recordAttrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		recordAttrs = append(recordAttrs, a)
+		return true
+	})
+
+	var scope string
+	var attrSpan SpanContext
+	for _, attr := range SetAttrsAtPath(h.attrs, h.groups, recordAttrs) {
+		value := attr.Value.Resolve()
+		switch attr.Key {
+		case LoggerKey:
+			scope = value.String()
+		case ErrorKey:
+			record.Attributes = append(record.Attributes, otlpExceptionAttrs(attr.Value)...)
+		case TraceIDKey:
+			decodeLowerHex(attrSpan.TraceID[:], value.String())
+		case SpanIDKey:
+			decodeLowerHex(attrSpan.SpanID[:], value.String())
+		case TraceFlagsKey:
+			var flags [1]byte
+			decodeLowerHex(flags[:], value.String())
+			attrSpan.Flags = flags[0]
+		default:
+			record.Attributes = append(record.Attributes, otlpKeyValues([]slog.Attr{{Key: attr.Key, Value: value}})...)
+		}
+	}
+
+	span, ok := SpanContext{}, false
+	if ctx != nil {
+		span, ok = registeredTraceExtractor(ctx)
+	}
+	if !ok || !span.IsValid() {
+		span = attrSpan
+	}
+	if span.IsValid() {
+		record.TraceID = hex.EncodeToString(span.TraceID[:])
+		record.SpanID = hex.EncodeToString(span.SpanID[:])
+		record.Flags = uint32(span.Flags)
+	}
+
+	data, err := json.Marshal(otlpQueuedRecord{Scope: scope, Record: record})
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to encode OTLP record")
+	}
+	_, err = h.exporter.shipper.Write(data)
+	return err
+}
+
+// encodeBatch groups queued records by scope into an ExportLogsServiceRequest
+func (e *OTLPExporter) encodeBatch(records [][]byte) []byte {
+	var scopes []otlpScopeLogs
+	index := map[string]int{}
+	for _, data := range records {
+		var queued struct {
+			Scope  string          `json:"scope"`
+			Record json.RawMessage `json:"record"`
+		}
+		if err := json.Unmarshal(data, &queued); err != nil {
+			continue
+		}
+		i, ok := index[queued.Scope]
+		if !ok {
+			i = len(scopes)
+			index[queued.Scope] = i
+			scopes = append(scopes, otlpScopeLogs{Scope: otlpScope{Name: queued.Scope}})
+		}
+		scopes[i].LogRecords = append(scopes[i].LogRecords, queued.Record)
+	}
+
+	body, _ := json.Marshal(otlpExportRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource:  otlpResource{Attributes: e.resource},
+			ScopeLogs: scopes,
+		}},
+	})
+	return body
+}
+
+// otlpSeverity maps a level onto the OTLP severity numbers, in which each
+// of TRACE, DEBUG, INFO, WARN, ERROR and FATAL spans four numbers
+func otlpSeverity(level slog.Level) int {
+	return min(max(int(level-LevelInfo)+9, 1), 24)
+}
+
+// otlpExceptionAttrs returns the exception.* attributes for an error
+// attribute, which is either an error or a group carrying its text and
+// backtrace.  Other members of the group are kept as the error attribute.
+func otlpExceptionAttrs(value slog.Value) []otlpKeyValue {
+	var message, stacktrace, kind string
+	var rest []slog.Attr
+
+	if err, ok := value.Any().(error); ok {
+		message = err.Error()
+		stacktrace = strings.TrimSpace(string(errors.BackTrace(err)))
+		kind = fmt.Sprintf("%T", err)
+	} else if value = value.Resolve(); value.Kind() == slog.KindGroup {
+		for _, attr := range value.Group() {
+			switch attr.Key {
+			case ErrorTextKey:
+				message = attr.Value.Resolve().String()
+			case ErrorBacktraceKey:
+				stacktrace = attr.Value.Resolve().String()
+			default:
+				rest = append(rest, attr)
+			}
+		}
+	} else {
+		message = syslogValueString(value)
+	}
+
+	var result []otlpKeyValue
+	if kind != "" {
+		result = append(result, otlpKeyValue{Key: "exception.type", Value: otlpValue(slog.StringValue(kind))})
+	}
+	if message != "" {
+		result = append(result, otlpKeyValue{Key: "exception.message", Value: otlpValue(slog.StringValue(message))})
+	}
+	if stacktrace != "" {
+		result = append(result, otlpKeyValue{Key: "exception.stacktrace", Value: otlpValue(slog.StringValue(stacktrace))})
+	}
+	if len(rest) > 0 {
+		result = append(result, otlpKeyValues([]slog.Attr{{Key: ErrorKey, Value: slog.GroupValue(rest...)}})...)
+	}
+	return result
+}
+
+// otlpKeyValues converts attributes to OTLP key-values, inlining groups
+// with empty keys and omitting empty attributes
+func otlpKeyValues(attrs []slog.Attr) []otlpKeyValue {
+	var result []otlpKeyValue
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		if value.Kind() == slog.KindGroup {
+			if len(value.Group()) == 0 {
+				continue
+			}
+			if attr.Key == "" {
+				result = append(result, otlpKeyValues(value.Group())...)
+				continue
+			}
+		} else if attr.Key == "" {
+			continue
+		}
+		result = append(result, otlpKeyValue{Key: attr.Key, Value: otlpValue(value)})
+	}
+	return result
+}
+
+// otlpValue converts a resolved value to an OTLP AnyValue.  64-bit integers
+// are strings, as required by the protobuf JSON mapping.
+func otlpValue(value slog.Value) otlpAnyValue {
+	switch value.Kind() {
+	case slog.KindBool:
+		b := value.Bool()
+		return otlpAnyValue{BoolValue: &b}
+	case slog.KindInt64:
+		s := strconv.FormatInt(value.Int64(), 10)
+		return otlpAnyValue{IntValue: &s}
+	case slog.KindUint64:
+		s := strconv.FormatUint(value.Uint64(), 10)
+		if value.Uint64() > math.MaxInt64 {
+			return otlpAnyValue{StringValue: &s}
+		}
+		return otlpAnyValue{IntValue: &s}
+	case slog.KindFloat64:
+		f := jsonFloat64(value.Float64())
+		return otlpAnyValue{DoubleValue: &f}
+	case slog.KindGroup:
+		return otlpAnyValue{KvlistValue: &otlpKvlistValue{Values: otlpKeyValues(value.Group())}}
+	case slog.KindAny:
+		if b, ok := value.Any().([]byte); ok {
+			return otlpAnyValue{BytesValue: b}
+		}
+	}
+	s := syslogValueString(value)
+	return otlpAnyValue{StringValue: &s}
+}
+
+// otlpQueuedRecord is the shipper's encoding of a record awaiting its batch
+type otlpQueuedRecord struct {
+	Scope  string        `json:"scope"`
+	Record otlpLogRecord `json:"record"`
+}
+
+type otlpExportRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope         `json:"scope"`
+	LogRecords []json.RawMessage `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name,omitempty"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano,omitempty"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	Flags                uint32         `json:"flags,omitempty"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string          `json:"stringValue,omitempty"`
+	BoolValue   *bool            `json:"boolValue,omitempty"`
+	IntValue    *string          `json:"intValue,omitempty"`
+	DoubleValue *jsonFloat64     `json:"doubleValue,omitempty"`
+	KvlistValue *otlpKvlistValue `json:"kvlistValue,omitempty"`
+	BytesValue  []byte           `json:"bytesValue,omitempty"`
+}
+
+type otlpKvlistValue struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+// jsonFloat64 encodes non-finite values as the strings "NaN", "Infinity"
+// and "-Infinity" of the protobuf JSON mapping, which encoding/json rejects
+type jsonFloat64 float64
+
+func (f jsonFloat64) MarshalJSON() ([]byte, error) {
+	switch v := float64(f); {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Infinity"`), nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, errors.WrapSentinel(err, "failed to encode double")
+		}
+		return data, nil
+	}
+}
+
+func (f *jsonFloat64) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case `"NaN"`:
+		*f = jsonFloat64(math.NaN())
+	case `"Infinity"`:
+		*f = jsonFloat64(math.Inf(1))
+	case `"-Infinity"`:
+		*f = jsonFloat64(math.Inf(-1))
+	default:
+		if err := json.Unmarshal(data, (*float64)(f)); err != nil {
+			return errors.WrapSentinel(err, "failed to decode double")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package log
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+// otlpCollector records the export requests posted to it
+type otlpCollector struct {
+	mu       sync.Mutex
+	requests []map[string]any
+}
+
+func (c *otlpCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Encoding") != "gzip" || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	zr, err := gzip.NewReader(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var request map[string]any
+	if err := json.NewDecoder(zr).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, request)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	collector := new(otlpCollector)
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	e, err := NewOTLPExporter(OTLPOptions{
+		HTTPShipperOptions: HTTPShipperOptions{URL: server.URL},
+		ServiceName:        "svc",
+		Resource:           []slog.Attr{slog.String("host.name", "box")},
+	})
+	assert.NoError(t, err)
+
+	span, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithSpanContext(context.Background(), span)
+
+	logger := slog.New(e.Handler())
+	logger.With(LoggerKey, "http").WithGroup("request").InfoContext(ctx, "served", "status", 200, "ok", true)
+	logger.With(LoggerKey, "db").Error("failed", ErrorKey, errors.NewSentinel("broken"))
+	logger.With(LoggerKey, "http").Warn("slow", "elapsed", 1.5)
+	logger.Debug("skipped")
+
+	assert.NoError(t, e.Close(context.Background()))
+
+	assert.Len(t, collector.requests, 1)
+	resourceLogs := collector.requests[0]["resourceLogs"].([]any)[0].(map[string]any)
+	assert.Equal(t, []any{
+		map[string]any{"key": "service.name", "value": map[string]any{"stringValue": "svc"}},
+		map[string]any{"key": "host.name", "value": map[string]any{"stringValue": "box"}},
+	}, resourceLogs["resource"].(map[string]any)["attributes"])
+
+	scopeLogs := resourceLogs["scopeLogs"].([]any)
+	assert.Len(t, scopeLogs, 2)
+
+	httpScope := scopeLogs[0].(map[string]any)
+	assert.Equal(t, map[string]any{"name": "http"}, httpScope["scope"])
+	httpRecords := httpScope["logRecords"].([]any)
+	assert.Len(t, httpRecords, 2)
+
+	served := httpRecords[0].(map[string]any)
+	assert.Equal(t, float64(9), served["severityNumber"])
+	assert.Equal(t, "INFO", served["severityText"])
+	assert.Equal(t, map[string]any{"stringValue": "served"}, served["body"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", served["traceId"])
+	assert.Equal(t, "00f067aa0ba902b7", served["spanId"])
+	assert.Equal(t, float64(1), served["flags"])
+	assert.NotEmpty(t, served["timeUnixNano"])
+	assert.Equal(t, []any{
+		map[string]any{"key": "request", "value": map[string]any{"kvlistValue": map[string]any{"values": []any{
+			map[string]any{"key": "status", "value": map[string]any{"intValue": "200"}},
+			map[string]any{"key": "ok", "value": map[string]any{"boolValue": true}},
+		}}}},
+	}, served["attributes"])
+
+	slow := httpRecords[1].(map[string]any)
+	assert.Equal(t, float64(13), slow["severityNumber"])
+	assert.Equal(t, []any{
+		map[string]any{"key": "elapsed", "value": map[string]any{"doubleValue": 1.5}},
+	}, slow["attributes"])
+
+	dbScope := scopeLogs[1].(map[string]any)
+	assert.Equal(t, map[string]any{"name": "db"}, dbScope["scope"])
+	failed := dbScope["logRecords"].([]any)[0].(map[string]any)
+	assert.Equal(t, float64(17), failed["severityNumber"])
+	exception := map[string]any{}
+	for _, attr := range failed["attributes"].([]any) {
+		kv := attr.(map[string]any)
+		exception[kv["key"].(string)] = kv["value"].(map[string]any)["stringValue"]
+	}
+	assert.Equal(t, "broken", exception["exception.message"])
+	assert.NotEmpty(t, exception["exception.type"])
+}
+
+func TestOTLPValue_NonFinite(t *testing.T) {
+	for _, test := range []struct {
+		value    float64
+		expected string
+	}{
+		{value: math.NaN(), expected: `{"doubleValue":"NaN"}`},
+		{value: math.Inf(1), expected: `{"doubleValue":"Infinity"}`},
+		{value: math.Inf(-1), expected: `{"doubleValue":"-Infinity"}`},
+		{value: 0.5, expected: `{"doubleValue":0.5}`},
+	} {
+		data, err := json.Marshal(otlpValue(slog.Float64Value(test.value)))
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, string(data))
+	}
+}
+
+func TestOTLPSeverity(t *testing.T) {
+	assert.Equal(t, 1, otlpSeverity(LevelTrace))
+	assert.Equal(t, 5, otlpSeverity(LevelDebug))
+	assert.Equal(t, 9, otlpSeverity(LevelInfo))
+	assert.Equal(t, 13, otlpSeverity(LevelWarn))
+	assert.Equal(t, 17, otlpSeverity(LevelError))
+	assert.Equal(t, 21, otlpSeverity(LevelError+4))
+	assert.Equal(t, 1, otlpSeverity(LevelTrace-10))
+	assert.Equal(t, 24, otlpSeverity(LevelError+100))
+}
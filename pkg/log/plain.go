@@ -36,6 +36,9 @@ func NewPlainHandler(out io.Writer, opts *slog.HandlerOptions) *PlainHandler {
 }
 
 func (h *PlainHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if override, ok := levelFromContext(ctx); ok {
+		return level >= override
+	}
 	return level >= h.opts.Level.Level()
 }
 
@@ -0,0 +1,291 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	slogmulti "github.com/samber/slog-multi"
+)
+
+const (
+	SampledMessageKey = "sampled_msg"
+	SuppressedKey     = "suppressed"
+)
+
+// SamplingPolicy limits the records logged with one message.  A policy with
+// First of zero does not sample.
+type SamplingPolicy struct {
+	// First is the number of records passed in each interval.
+	First int
+	// Thereafter passes every Mth record beyond First in the interval.  Zero
+	// suppresses them all.
+	Thereafter int
+}
+
+type SamplingOptions struct {
+	// Interval is the period over which First records pass.  Defaults to 1s.
+	Interval time.Duration
+	// Levels holds the policy for each level.  Records at LevelError and
+	// above are never sampled.
+	Levels map[slog.Level]SamplingPolicy
+	// Default is the policy for levels missing from Levels.
+	Default SamplingPolicy
+	// SummaryInterval is the period between records reporting how many
+	// records were suppressed.  Defaults to 1m.
+	SummaryInterval time.Duration
+}
+
+// Sampler limits the rate of records sharing a logger, level and message,
+// so that a hot loop cannot flood the outputs.  Suppressed records are
+// reported periodically, at their own level, through the handler of the
+// last record suppressed.
+type Sampler struct {
+	opts       SamplingOptions
+	mu         *sync.Mutex
+	keys       map[samplingKey]*samplingState
+	suppressed atomic.Uint64
+
+	closed     chan struct{}
+	stopped    chan struct{}
+	unregister func()
+}
+
+type samplingKey struct {
+	logger  string
+	level   slog.Level
+	message string
+}
+
+type samplingState struct {
+	window     time.Time
+	count      int
+	suppressed uint64
+	last       time.Time
+	handler    slog.Handler
+}
+
+func NewSampler(opts SamplingOptions) *Sampler {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.SummaryInterval <= 0 {
+		opts.SummaryInterval = time.Minute
+	}
+
+	s := &Sampler{
+		opts:    opts,
+		mu:      &sync.Mutex{},
+		keys:    map[samplingKey]*samplingState{},
+		closed:  make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go s.run()
+	s.unregister = RegisterLifecycle(s)
+	return s
+}
+
+var registeredSampler *Sampler
+
+// RegisterSampler applies s to loggers created by NewLogger.  Nil disables
+// sampling.
+func RegisterSampler(s *Sampler) {
+	registeredSampler = s
+}
+
+// NewSamplingMiddleware returns middleware sampling records with the
+// registered sampler, if any.
+func NewSamplingMiddleware() slogmulti.Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return &SamplingHandler{
+			sampler: func() *Sampler { return registeredSampler },
+			next:    next,
+		}
+	}
+}
+
+// Middleware returns middleware sampling records with s.
+func (s *Sampler) Middleware() slogmulti.Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return &SamplingHandler{
+			sampler: func() *Sampler { return s },
+			next:    next,
+		}
+	}
+}
+
+// Suppressed returns the number of records suppressed by the sampler.
+func (s *Sampler) Suppressed() uint64 {
+	return s.suppressed.Load()
+}
+
+// Flush logs the summary of records suppressed since the last summary.
+func (s *Sampler) Flush(ctx context.Context) error {
+	s.summarize(ctx, time.Now())
+	return nil
+}
+
+// Close stops periodic summaries, and logs a final summary.
+func (s *Sampler) Close(ctx context.Context) error {
+	select {
+	case <-s.closed:
+		return nil
+	default:
+	}
+
+	s.unregister()
+	close(s.closed)
+	<-s.stopped
+	return s.Flush(ctx)
+}
+
+func (s *Sampler) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.opts.SummaryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closed:
+			return
+		case now := <-ticker.C:
+			s.summarize(context.Background(), now)
+		}
+	}
+}
+
+// policy returns the policy for records at level
+func (s *Sampler) policy(level slog.Level) SamplingPolicy {
+	if level >= LevelError {
+		return SamplingPolicy{}
+	}
+	if policy, ok := s.opts.Levels[level]; ok {
+		return policy
+	}
+	return s.opts.Default
+}
+
+// sample reports whether a record passes, counting those that do not
+func (s *Sampler) sample(key samplingKey, now time.Time, handler slog.Handler) bool {
+	policy := s.policy(key.level)
+	if policy.First <= 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.keys[key]
+	if !ok {
+		state = &samplingState{window: now}
+		s.keys[key] = state
+	}
+	if now.Sub(state.window) >= s.opts.Interval {
+		state.window = now
+		state.count = 0
+	}
+	state.count++
+	state.last = now
+
+	if state.count <= policy.First {
+		return true
+	}
+	if policy.Thereafter > 0 && (state.count-policy.First)%policy.Thereafter == 0 {
+		return true
+	}
+
+	state.suppressed++
+	state.handler = handler
+	s.suppressed.Add(1)
+	return false
+}
+
+// summarize logs the records suppressed for each key, and forgets keys
+// idle for longer than an interval
+func (s *Sampler) summarize(ctx context.Context, now time.Time) {
+	type summary struct {
+		key        samplingKey
+		suppressed uint64
+		handler    slog.Handler
+	}
+
+	var summaries []summary
+	s.mu.Lock()
+	for key, state := range s.keys {
+		if state.suppressed > 0 {
+			summaries = append(summaries, summary{key: key, suppressed: state.suppressed, handler: state.handler})
+			state.suppressed = 0
+			state.handler = nil
+		}
+		if now.Sub(state.last) >= s.opts.Interval {
+			delete(s.keys, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, summary := range summaries {
+		record := slog.NewRecord(now, summary.key.level, "Suppressed sampled log records", 0)
+		record.AddAttrs(
+			slog.String(SampledMessageKey, summary.key.message),
+			slog.Uint64(SuppressedKey, summary.suppressed))
+		if err := summary.handler.Handle(ctx, record); err != nil {
+			LoggingLogger().Error("Failed to log sampling summary", ErrorKey, err)
+		}
+	}
+}
+
+// SamplingHandler passes records to the next handler as allowed by its
+// sampler, keyed by the LoggerKey attribute, level and message.
+type SamplingHandler struct {
+	sampler func() *Sampler
+	next    slog.Handler
+	logger  string
+	grouped bool
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if s := h.sampler(); s != nil {
+		now := r.Time
+		if now.IsZero() {
+			now = time.Now()
+		}
+		key := samplingKey{logger: h.logger, level: r.Level, message: r.Message}
+		if !s.sample(key, now, h.next) {
+			return nil
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.next = h.next.WithAttrs(attrs)
+	if !h.grouped {
+		for _, attr := range attrs {
+			if attr.Key == LoggerKey {
+				h2.logger = attr.Value.Resolve().String()
+			}
+		}
+	}
+	return &h2
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.grouped = true
+	return &h2
+}
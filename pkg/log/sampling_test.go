@@ -0,0 +1,90 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampler(t *testing.T) {
+	s := NewSampler(SamplingOptions{
+		Interval: time.Second,
+		Levels: map[slog.Level]SamplingPolicy{
+			LevelDebug: {First: 1},
+		},
+		Default: SamplingPolicy{First: 2, Thereafter: 3},
+	})
+	defer s.Close(context.Background())
+
+	buffer := new(bytes.Buffer)
+	h := s.Middleware()(slog.NewTextHandler(buffer, &slog.HandlerOptions{
+		Level: LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger := h.WithAttrs([]slog.Attr{slog.String(LoggerKey, "svc")})
+	other := h.WithAttrs([]slog.Attr{slog.String(LoggerKey, "other")})
+
+	start := time.Now()
+	log := func(h slog.Handler, offset time.Duration, level slog.Level, msg string) {
+		_ = h.Handle(context.Background(), slog.NewRecord(start.Add(offset), level, msg, 0))
+	}
+
+	// default policy passes 1, 2, 5 and 8
+	for i := 0; i < 8; i++ {
+		log(logger, 0, LevelWarn, "retry")
+	}
+	// keyed separately by logger and level
+	log(other, 0, LevelWarn, "retry")
+	log(logger, 0, LevelDebug, "retry")
+	log(logger, 0, LevelDebug, "retry")
+	// never sampled
+	for i := 0; i < 3; i++ {
+		log(logger, 0, LevelError, "failed")
+	}
+	// a new interval
+	log(logger, time.Second, LevelWarn, "retry")
+
+	assert.Equal(t, uint64(5), s.Suppressed())
+	assert.Equal(t, 5, strings.Count(buffer.String(), "level=WARN msg=retry logger=svc"))
+	assert.Equal(t, 1, strings.Count(buffer.String(), "logger=other"))
+	assert.Equal(t, 1, strings.Count(buffer.String(), "level=DEBUG"))
+	assert.Equal(t, 3, strings.Count(buffer.String(), "msg=failed"))
+
+	buffer.Reset()
+	assert.NoError(t, s.Flush(context.Background()))
+	summaries := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.ElementsMatch(t, []string{
+		`level=WARN msg="Suppressed sampled log records" logger=svc sampled_msg=retry suppressed=4`,
+		`level=DEBUG msg="Suppressed sampled log records" logger=svc sampled_msg=retry suppressed=1`,
+	}, summaries)
+
+	buffer.Reset()
+	assert.NoError(t, s.Flush(context.Background()))
+	assert.Empty(t, buffer.String())
+}
+
+func TestNewLogger_Sampling(t *testing.T) {
+	s := NewSampler(SamplingOptions{Default: SamplingPolicy{First: 1}})
+	RegisterSampler(s)
+	defer func() {
+		RegisterSampler(nil)
+		_ = s.Close(context.Background())
+	}()
+
+	logger := NewLogger("sampled")
+	logger.Info("repeated")
+	logger.Info("repeated")
+	logger.Error("repeated")
+
+	assert.Equal(t, uint64(1), s.Suppressed())
+}
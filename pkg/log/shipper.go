@@ -0,0 +1,559 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+// OverflowPolicy chooses which records to drop when a bounded buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDropNewest drops the record being added.
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest buffered records.
+	OverflowDropOldest
+	// OverflowBlock waits for room in the buffer.
+	OverflowBlock
+	// OverflowDropBelowLevel drops the record being added if it is below a
+	// level, and otherwise waits for room in the buffer.
+	OverflowDropBelowLevel
+)
+
+var errShipperClosed = errors.NewSentinel("log shipper closed")
+
+// shipperFailure classifies a failed request
+type shipperFailure int
+
+const (
+	// shipperRetry is a transient failure, retried after a backoff.
+	shipperRetry shipperFailure = iota
+	// shipperRejected is a rejection of the records sent, which are split
+	// to find those rejected alone.
+	shipperRejected
+	// shipperFailed is a failure of every request, such as one with bad
+	// credentials, which fails the whole batch without retrying it.
+	shipperFailed
+)
+
+type HTTPShipperOptions struct {
+	// URL receives each batch in a POST request.
+	URL string
+	// Client defaults to a client with a 30s timeout.
+	Client *http.Client
+	// Header is added to each request.
+	Header http.Header
+	// BatchSize is the most records sent in one request.  Defaults to 500.
+	BatchSize int
+	// BatchInterval is the longest a record waits before being sent.
+	// Defaults to 1s.
+	BatchInterval time.Duration
+	// MaxBuffer bounds the bytes of records awaiting delivery.  Defaults to 8MiB.
+	MaxBuffer int
+	// Overflow chooses which records to drop when MaxBuffer is reached.
+	// Only OverflowDropNewest and OverflowDropOldest are supported.
+	Overflow OverflowPolicy
+	// MaxRetries is the number of times a failed batch is retried before it
+	// is dropped.  Defaults to 5.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the exponential delay between retries.
+	// Default to 500ms and 30s.  MaxBackoff also caps delays requested by
+	// Retry-After.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Level defaults to LevelInfo.
+	Level slog.Leveler
+	// Spool, if set, stores batches that could not be delivered, and those
+	// still queued on Close, instead of dropping them.  Spooled records are
+	// sent before newer records once the endpoint recovers.
+	Spool *Spool
+}
+
+// HTTPShipper posts records to an HTTP endpoint in gzipped batches of JSON
+// lines.  Failed batches are retried with exponential backoff, honoring
+// Retry-After.  A batch rejected with 400, 413 or 422 is split, to drop only
+// the records rejected alone.  Other client errors, such as 401, fail the
+// whole batch, which is spooled or dropped, and delay the next batch by the
+// backoff.  Its own failures are reported through LoggingLogger.
+type HTTPShipper struct {
+	opts        HTTPShipperOptions
+	contentType string
+	encodeBatch func(records [][]byte) []byte
+
+	mu       *sync.Mutex
+	queue    [][]byte
+	queued   int
+	closed   bool
+	dropped  atomic.Uint64
+	reported uint64
+
+	// replayAt and replayBackoff delay replay of the spool after a failure
+	replayAt      time.Time
+	replayBackoff time.Duration
+
+	wake       chan struct{}
+	flushes    chan chan struct{}
+	ctx        context.Context
+	cancel     context.CancelFunc
+	stopped    chan struct{}
+	unregister func()
+}
+
+func NewHTTPShipper(opts HTTPShipperOptions) (*HTTPShipper, error) {
+	s, err := newHTTPShipper(opts)
+	if err != nil {
+		return nil, err
+	}
+	s.contentType = "application/x-ndjson"
+	s.encodeBatch = func(records [][]byte) []byte {
+		return bytes.Join(records, nil)
+	}
+	s.start()
+	return s, nil
+}
+
+// NewHTTPShipperFactory returns a factory for RegisterRemoteHandlerFactory
+// sharing one shipper.
+func NewHTTPShipperFactory(opts HTTPShipperOptions) (RemoteHandlerFactory, error) {
+	s, err := NewHTTPShipper(opts)
+	if err != nil {
+		return nil, err
+	}
+	handler := s.Handler()
+	return func() slog.Handler { return handler }, nil
+}
+
+// newHTTPShipper returns a shipper with default options, which the caller
+// completes with a batch encoding before starting it
+func newHTTPShipper(opts HTTPShipperOptions) (*HTTPShipper, error) {
+	if opts.URL == "" {
+		return nil, errors.NewCodeError(errors.CodeInvalidArgument, nil, "missing log shipper URL")
+	}
+	if opts.Overflow != OverflowDropNewest && opts.Overflow != OverflowDropOldest {
+		return nil, errors.NewCodeError(errors.CodeInvalidArgument, nil, "unsupported log shipper overflow policy %d", opts.Overflow)
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.BatchInterval <= 0 {
+		opts.BatchInterval = time.Second
+	}
+	if opts.MaxBuffer <= 0 {
+		opts.MaxBuffer = 8 << 20
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 5
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 500 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	if opts.Level == nil {
+		opts.Level = LevelInfo
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HTTPShipper{
+		opts:    opts,
+		mu:      &sync.Mutex{},
+		wake:    make(chan struct{}, 1),
+		flushes: make(chan chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}, nil
+}
+
+// start sends records in the background until the shipper is closed
+func (s *HTTPShipper) start() {
+	go s.run()
+	s.unregister = RegisterLifecycle(s)
+}
+
+// Handler returns a handler encoding records as JSON lines for the shipper.
+func (s *HTTPShipper) Handler() slog.Handler {
+	return NewContextLevelHandler(slog.NewJSONHandler(s, &slog.HandlerOptions{
+		Level: s.opts.Level,
+	}))
+}
+
+// Write queues one encoded record, dropping records according to the
+// overflow policy if the buffer is full.
+func (s *HTTPShipper) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, errShipperClosed
+	}
+
+	if s.queued+len(p) > s.opts.MaxBuffer {
+		if s.opts.Overflow != OverflowDropOldest || len(p) > s.opts.MaxBuffer {
+			s.dropped.Add(1)
+			return len(p), nil
+		}
+		for s.queued+len(p) > s.opts.MaxBuffer {
+			s.queued -= len(s.queue[0])
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			s.dropped.Add(1)
+		}
+	}
+
+	s.queue = append(s.queue, bytes.Clone(p))
+	s.queued += len(p)
+
+	if len(s.queue) >= s.opts.BatchSize {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of records dropped since the shipper started.
+func (s *HTTPShipper) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Flush sends every queued record, waiting until ctx is done.
+func (s *HTTPShipper) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case s.flushes <- done:
+	case <-s.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting records and sends those queued until ctx is done.
+// Records still queued after that are dropped.
+func (s *HTTPShipper) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	s.unregister()
+	err := s.Flush(ctx)
+	s.cancel()
+	<-s.stopped
+	return err
+}
+
+func (s *HTTPShipper) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.opts.BatchInterval)
+	defer ticker.Stop()
+
+	for {
+		var done chan struct{}
+		select {
+		case <-s.ctx.Done():
+			s.dropQueued()
+			s.reportDropped()
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		case done = <-s.flushes:
+		}
+
+		s.sendQueued()
+		s.reportDropped()
+		if done != nil {
+			close(done)
+		}
+	}
+}
+
+// sendQueued sends batches until the queue is empty.  While spooled
+// records remain undelivered, batches are spooled behind them instead.
+func (s *HTTPShipper) sendQueued() {
+	spooling := !s.replaySpool()
+	if !spooling && time.Now().Before(s.replayAt) {
+		// records wait out the backoff in the queue, bounded by MaxBuffer
+		return
+	}
+
+	for {
+		s.mu.Lock()
+		n := min(len(s.queue), s.opts.BatchSize)
+		batch := s.queue[:n:n]
+		s.queue = s.queue[n:]
+		for _, record := range batch {
+			s.queued -= len(record)
+		}
+		s.mu.Unlock()
+
+		if len(batch) == 0 {
+			return
+		}
+
+		if spooling {
+			s.spoolBatch(batch)
+			continue
+		}
+
+		sent, retryAfter, err := s.sendBatch(batch, s.opts.MaxRetries)
+		if err == nil {
+			s.replayBackoff = 0
+			continue
+		}
+
+		LoggingLogger().Error("Failed to ship log batch",
+			"url", s.opts.URL,
+			"records", len(batch)-sent,
+			ErrorKey, err)
+		spooling = s.spoolBatch(batch[sent:])
+		s.backoffReplay(retryAfter)
+		if !spooling {
+			return
+		}
+	}
+}
+
+// replaySpool sends spooled records, returning false if any remain.  After
+// a failure, replay waits out the same backoff as a retried batch.
+func (s *HTTPShipper) replaySpool() bool {
+	if s.opts.Spool == nil {
+		return true
+	}
+
+	for {
+		records, err := s.opts.Spool.Peek(s.opts.BatchSize)
+		if err != nil {
+			LoggingLogger().Error("Failed to read log spool",
+				ErrorKey, err)
+			return false
+		}
+		if len(records) == 0 {
+			s.replayBackoff = 0
+			return true
+		}
+		if time.Now().Before(s.replayAt) {
+			return false
+		}
+
+		sent, retryAfter, err := s.sendBatch(records, 0)
+		if sent > 0 {
+			if err := s.opts.Spool.Commit(sent); err != nil {
+				LoggingLogger().Error("Failed to commit log spool",
+					ErrorKey, err)
+				return false
+			}
+		}
+		if err != nil {
+			s.backoffReplay(retryAfter)
+			return false
+		}
+		s.replayBackoff = 0
+	}
+}
+
+// backoffReplay delays the next replay of the spool by the delay requested
+// by the endpoint, or else by an exponential backoff
+func (s *HTTPShipper) backoffReplay(retryAfter time.Duration) {
+	s.replayBackoff = min(max(s.replayBackoff*2, s.opts.MinBackoff), s.opts.MaxBackoff)
+	delay := s.replayBackoff
+	if retryAfter > 0 {
+		delay = retryAfter
+	}
+	s.replayAt = time.Now().Add(delay)
+}
+
+// spoolBatch stores a batch in the spool, or drops it if there is no spool,
+// returning true if it was spooled
+func (s *HTTPShipper) spoolBatch(batch [][]byte) bool {
+	if s.opts.Spool == nil {
+		s.dropped.Add(uint64(len(batch)))
+		return false
+	}
+
+	if err := s.opts.Spool.Append(batch...); err != nil {
+		s.dropped.Add(uint64(len(batch)))
+		LoggingLogger().Error("Failed to spool log batch",
+			"records", len(batch),
+			ErrorKey, err)
+		return false
+	}
+	return true
+}
+
+func (s *HTTPShipper) dropQueued() {
+	s.mu.Lock()
+	queue := s.queue
+	s.queue = nil
+	s.queued = 0
+	s.mu.Unlock()
+
+	s.spoolBatch(queue)
+}
+
+func (s *HTTPShipper) reportDropped() {
+	dropped := s.dropped.Load()
+	if dropped == s.reported {
+		return
+	}
+	LoggingLogger().Warn("Dropped log records",
+		"url", s.opts.URL,
+		"count", dropped-s.reported)
+	s.reported = dropped
+}
+
+// sendBatch posts a batch, retrying transient failures up to retries times.
+// A batch the endpoint rejects is split, so that only the records it rejects
+// alone are dropped.  It returns the number of leading records delivered or
+// dropped, and on failure the delay requested by the endpoint.
+func (s *HTTPShipper) sendBatch(batch [][]byte, retries int) (int, time.Duration, error) {
+	body, err := gzipBytes(s.encodeBatch(batch))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	backoff := s.opts.MinBackoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, failure, err := s.post(body)
+		switch {
+		case err == nil:
+			return len(batch), 0, nil
+		case failure == shipperRejected:
+			return s.splitRejected(batch, retries, err)
+		case failure == shipperFailed || attempt >= retries:
+			return 0, retryAfter, err
+		}
+
+		delay := backoff
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		backoff = min(backoff*2, s.opts.MaxBackoff)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return 0, 0, errors.FromContext(s.ctx)
+		case <-timer.C:
+		}
+	}
+}
+
+// splitRejected sends each half of a rejected batch, dropping a record the
+// endpoint rejects on its own
+func (s *HTTPShipper) splitRejected(batch [][]byte, retries int, err error) (int, time.Duration, error) {
+	if len(batch) == 1 {
+		s.dropped.Add(1)
+		LoggingLogger().Error("Log record rejected",
+			"url", s.opts.URL,
+			ErrorKey, err)
+		return 1, 0, nil
+	}
+
+	half := len(batch) / 2
+	sent, retryAfter, err := s.sendBatch(batch[:half], retries)
+	if err != nil {
+		return sent, retryAfter, err
+	}
+	rest, retryAfter, err := s.sendBatch(batch[half:], retries)
+	return sent + rest, retryAfter, err
+}
+
+// post sends one request.  On failure, it returns the delay requested by
+// the server, or zero to use the backoff, and the kind of failure.
+func (s *HTTPShipper) post(body []byte) (time.Duration, shipperFailure, error) {
+	request, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, shipperFailed, errors.WrapSentinel(err, "failed to create log shipper request")
+	}
+	for name, values := range s.opts.Header {
+		request.Header[name] = values
+	}
+	request.Header.Set("Content-Type", s.contentType)
+	request.Header.Set("Content-Encoding", "gzip")
+
+	response, err := s.opts.Client.Do(request)
+	if err != nil {
+		return 0, shipperRetry, errors.WrapSentinel(err, "failed to send log shipper request")
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	_ = response.Body.Close()
+
+	switch status := response.StatusCode; {
+	case status < 300:
+		return 0, 0, nil
+	case status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable:
+		return parseRetryAfter(response.Header.Get("Retry-After"), s.opts.MaxBackoff), shipperRetry, shipperStatusError(response)
+	case status == http.StatusRequestTimeout || status >= 500:
+		return 0, shipperRetry, shipperStatusError(response)
+	case status == http.StatusBadRequest || status == http.StatusRequestEntityTooLarge ||
+		status == http.StatusUnprocessableEntity:
+		return 0, shipperRejected, shipperStatusError(response)
+	default:
+		// such as 401, 403 or 404, which would fail every record alike
+		return 0, shipperFailed, shipperStatusError(response)
+	}
+}
+
+func shipperStatusError(response *http.Response) error {
+	return errors.NewCodeError(errors.CodeForHTTPStatus(response.StatusCode), nil,
+		"log shipper received %s", response.Status)
+}
+
+// parseRetryAfter returns the delay in a Retry-After header, at most
+// maxDelay, or zero
+func parseRetryAfter(value string, maxDelay time.Duration) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		if seconds >= int(maxDelay/time.Second) {
+			return maxDelay
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return min(max(time.Until(t), 0), maxDelay)
+	}
+	return 0
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, errors.WrapSentinel(err, "failed to compress log batch")
+	}
+	if err := zw.Close(); err != nil {
+		return nil, errors.WrapSentinel(err, "failed to compress log batch")
+	}
+	return buf.Bytes(), nil
+}
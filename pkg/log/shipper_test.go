@@ -0,0 +1,218 @@
+package log
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// shipperCollector records the JSON lines posted to it, after first
+// responding with the given statuses
+type shipperCollector struct {
+	mu       sync.Mutex
+	statuses []int
+	requests int
+	lines    []string
+}
+
+func (c *shipperCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests++
+	if len(c.statuses) > 0 {
+		status := c.statuses[0]
+		c.statuses = c.statuses[1:]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		w.WriteHeader(status)
+		return
+	}
+
+	if r.Header.Get("Content-Encoding") != "gzip" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	zr, err := gzip.NewReader(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	body, _ := io.ReadAll(zr)
+	c.lines = append(c.lines, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+}
+
+func (c *shipperCollector) snapshot() (int, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests, c.lines
+}
+
+func TestHTTPShipper(t *testing.T) {
+	collector := new(shipperCollector)
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	s, err := NewHTTPShipper(HTTPShipperOptions{
+		URL:       server.URL,
+		BatchSize: 2,
+	})
+	assert.NoError(t, err)
+
+	logger := slog.New(s.Handler()).With(LoggerKey, "svc")
+	logger.Info("one")
+	logger.Info("two")
+	logger.Debug("skipped")
+	logger.Info("three")
+
+	assert.NoError(t, s.Close(context.Background()))
+
+	requests, lines := collector.snapshot()
+	assert.Equal(t, 2, requests)
+	if assert.Len(t, lines, 3) {
+		assert.Contains(t, lines[0], `"msg":"one","logger":"svc"`)
+		assert.Contains(t, lines[2], `"msg":"three"`)
+	}
+
+	_, err = s.Write([]byte("{}\n"))
+	assert.ErrorIs(t, err, errShipperClosed)
+}
+
+func TestHTTPShipper_Retry(t *testing.T) {
+	collector := &shipperCollector{
+		statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests},
+	}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	s, err := NewHTTPShipper(HTTPShipperOptions{
+		URL:        server.URL,
+		MinBackoff: time.Millisecond,
+	})
+	assert.NoError(t, err)
+	defer s.Close(context.Background())
+
+	start := time.Now()
+	slog.New(s.Handler()).Info("retried")
+	assert.NoError(t, s.Flush(context.Background()))
+
+	requests, lines := collector.snapshot()
+	assert.Equal(t, 3, requests)
+	assert.Len(t, lines, 1)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "Retry-After honored")
+	assert.Zero(t, s.Dropped())
+}
+
+func TestHTTPShipper_PermanentFailure(t *testing.T) {
+	collector := &shipperCollector{statuses: []int{http.StatusBadRequest}}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	s, err := NewHTTPShipper(HTTPShipperOptions{URL: server.URL})
+	assert.NoError(t, err)
+	defer s.Close(context.Background())
+
+	slog.New(s.Handler()).Info("rejected")
+	assert.NoError(t, s.Flush(context.Background()))
+
+	requests, _ := collector.snapshot()
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, uint64(1), s.Dropped())
+}
+
+func TestHTTPShipper_Unauthorized(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	s, err := NewHTTPShipper(HTTPShipperOptions{
+		URL:        server.URL,
+		BatchSize:  64,
+		MinBackoff: time.Hour,
+	})
+	assert.NoError(t, err)
+	defer s.Close(context.Background())
+
+	logger := slog.New(s.Handler())
+	for i := 0; i < 64; i++ {
+		logger.Info("unauthorized", "i", i)
+	}
+	assert.NoError(t, s.Flush(context.Background()))
+	assert.Equal(t, int32(1), requests.Load(), "batch neither split nor retried")
+	assert.Equal(t, uint64(64), s.Dropped())
+
+	logger.Info("waiting")
+	assert.NoError(t, s.Flush(context.Background()))
+	assert.Equal(t, int32(1), requests.Load(), "sending waits out the backoff")
+}
+
+func TestHTTPShipper_Overflow(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		overflow OverflowPolicy
+		expected []string
+	}{
+		{name: "DropNewest", overflow: OverflowDropNewest, expected: []string{"1\n", "2\n"}},
+		{name: "DropOldest", overflow: OverflowDropOldest, expected: []string{"3\n", "4\n"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := newHTTPShipper(HTTPShipperOptions{
+				URL:       "http://localhost",
+				MaxBuffer: 4,
+				Overflow:  test.overflow,
+			})
+			assert.NoError(t, err)
+
+			for _, record := range []string{"1\n", "2\n", "3\n", "4\n"} {
+				_, err = s.Write([]byte(record))
+				assert.NoError(t, err)
+			}
+
+			var queued []string
+			for _, record := range s.queue {
+				queued = append(queued, string(record))
+			}
+			assert.Equal(t, test.expected, queued)
+			assert.Equal(t, uint64(2), s.Dropped())
+		})
+	}
+}
+
+func TestHTTPShipper_UnsupportedOverflow(t *testing.T) {
+	for _, overflow := range []OverflowPolicy{OverflowBlock, OverflowDropBelowLevel} {
+		_, err := NewHTTPShipper(HTTPShipperOptions{
+			URL:      "http://localhost",
+			Overflow: overflow,
+		})
+		assert.Error(t, err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 2*time.Second, parseRetryAfter("2", time.Minute))
+	assert.Zero(t, parseRetryAfter("", time.Minute))
+	assert.Zero(t, parseRetryAfter("soon", time.Minute))
+	delay := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 2*time.Hour)
+	assert.InDelta(t, time.Hour, delay, float64(2*time.Second))
+
+	// clamped, including seconds that would overflow a Duration
+	assert.Equal(t, time.Minute, parseRetryAfter("3600", time.Minute))
+	assert.Equal(t, time.Minute, parseRetryAfter("99999999999", time.Minute))
+	delay = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), time.Minute)
+	assert.Equal(t, time.Minute, delay)
+}
@@ -0,0 +1,23 @@
+package log
+
+import (
+	"log/slog"
+)
+
+// signalLevels are the levels stepped through by HandleLevelSignals, most verbose first
+var signalLevels = []slog.Level{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError}
+
+// stepLevel returns the signal level the given number of steps from level,
+// where negative steps are more verbose
+func stepLevel(level slog.Level, steps int) slog.Level {
+	index := len(signalLevels) - 1
+	for i, signalLevel := range signalLevels {
+		if signalLevel >= level {
+			index = i
+			break
+		}
+	}
+
+	index = min(max(index+steps, 0), len(signalLevels)-1)
+	return signalLevels[index]
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package log
+
+import (
+	"context"
+)
+
+// HandleLevelSignals does nothing on platforms without SIGUSR1 and SIGUSR2.
+func HandleLevelSignals(ctx context.Context) {}
@@ -0,0 +1,58 @@
+//go:build unix
+
+package log
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleLevelSignals changes the level of every logger on receipt of a
+// signal, until ctx is done.  Each configured logger and pattern is stepped
+// from its own level:
+//
+//	SIGUSR1   one level more verbose, down to Trace
+//	SIGUSR2   one level less verbose, up to Error
+//	SIGHUP    restore the levels configured when HandleLevelSignals was called
+func HandleLevelSignals(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP)
+
+	original := snapshotLoggerLevels()
+
+	go func() {
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signals:
+				handleLevelSignal(sig, original)
+			}
+		}
+	}()
+}
+
+func handleLevelSignal(sig os.Signal, original loggerLevelsSnapshot) {
+	if sig == syscall.SIGHUP {
+		restoreLoggerLevels(original)
+		LoggingLogger().Info("Restored logger levels",
+			"signal", sig.String())
+		return
+	}
+
+	steps := 1
+	if sig == syscall.SIGUSR1 {
+		steps = -1
+	}
+
+	stepLoggerLevels(steps)
+
+	level, _ := GetLoggerLevel(RootLoggerName)
+	LoggingLogger().Info("Changed all logger levels",
+		"signal", sig.String(),
+		"level", LevelName(level))
+}
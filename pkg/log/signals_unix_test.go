@@ -0,0 +1,51 @@
+//go:build unix
+
+package log
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepLevel(t *testing.T) {
+	assert.Equal(t, LevelDebug, stepLevel(LevelInfo, -1))
+	assert.Equal(t, LevelTrace, stepLevel(LevelTrace, -1))
+	assert.Equal(t, LevelWarn, stepLevel(LevelInfo+1, 0))
+	assert.Equal(t, LevelError, stepLevel(LevelError, 1))
+}
+
+func TestHandleLevelSignals(t *testing.T) {
+	resetLoggerLevels(t)
+
+	SetLoggerLevel("svc.db", LevelWarn)
+	db := GetLoggerLeveler("svc.db")
+	http := GetLoggerLeveler("svc.http")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	HandleLevelSignals(ctx)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool {
+		return db.Level() == LevelInfo && http.Level() == LevelDebug
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+	assert.Eventually(t, func() bool {
+		return db.Level() == LevelWarn && http.Level() == LevelInfo
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+	assert.Eventually(t, func() bool {
+		return db.Level() == LevelError && http.Level() == LevelWarn
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+	assert.Eventually(t, func() bool {
+		return db.Level() == LevelWarn && http.Level() == LevelInfo
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,780 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+const (
+	spoolSegmentSuffix = ".spool"
+	spoolCursorName    = "cursor"
+	// spoolFrameHeaderSize is the record length and CRC-32 preceding each record
+	spoolFrameHeaderSize = 8
+	// spoolMaxRecordSize rejects corrupt lengths when reading
+	spoolMaxRecordSize = 64 << 20
+)
+
+var errSpoolClosed = errors.NewSentinel("log spool closed")
+
+type SpoolOptions struct {
+	// Dir holds the segment files.  It is created if necessary.
+	Dir string
+	// MaxSegmentSize starts a new segment once a segment reaches this many
+	// bytes.  Defaults to 4MiB.
+	MaxSegmentSize int64
+	// MaxSize bounds the bytes of all segments.  The oldest segments are
+	// dropped to stay within it.  Defaults to 256MiB.
+	MaxSize int64
+	// SyncInterval is the longest appended records wait to be synced to
+	// disk.  Defaults to 1s.  Negative syncs on every Append.
+	SyncInterval time.Duration
+	// SyncSize syncs appended records once this many bytes await syncing.
+	// Defaults to 1MiB.
+	SyncSize int64
+}
+
+// SpoolStats describes the records awaiting delivery.
+type SpoolStats struct {
+	Segments int
+	Records  int
+	Bytes    int64
+	// Dropped counts the records in segments dropped to stay within MaxSize.
+	Dropped uint64
+}
+
+type spoolSegment struct {
+	seq     uint64
+	size    int64
+	records int
+}
+
+// spoolPosition is the offset of a record within the segment sequence
+type spoolPosition struct {
+	seq     uint64
+	offset  int64
+	records int // records before offset in the segment
+}
+
+// Spool is a write-ahead queue of records stored in segment files, read in
+// order by a single consumer.  The read position is saved on Commit, so
+// that records survive restarts.  Records may be delivered again if the
+// process stops between delivering and committing them.
+type Spool struct {
+	opts     SpoolOptions
+	mu       *sync.Mutex
+	segments []spoolSegment // oldest first; the last is being written
+	active   *os.File
+	read     spoolPosition
+	peeked   []spoolPosition
+	dropped  uint64
+	unsynced int64
+	syncer   *time.Timer
+
+	unregister func()
+}
+
+func OpenSpool(opts SpoolOptions) (*Spool, error) {
+	if opts.MaxSegmentSize <= 0 {
+		opts.MaxSegmentSize = 4 << 20
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 256 << 20
+	}
+	if opts.SyncInterval == 0 {
+		opts.SyncInterval = time.Second
+	}
+	if opts.SyncSize <= 0 {
+		opts.SyncSize = 1 << 20
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, errors.WrapSentinel(err, "failed to create log spool directory")
+	}
+
+	s := &Spool{
+		opts: opts,
+		mu:   &sync.Mutex{},
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	s.unregister = RegisterLifecycle(LifecycleFuncs{
+		FlushFunc: func(context.Context) error { return s.Sync() },
+		CloseFunc: func(context.Context) error { return s.Close() },
+	})
+	return s, nil
+}
+
+// load scans the existing segments, truncating any torn records, and
+// restores the read position
+func (s *Spool) load() error {
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to list log spool segments")
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, spoolSegmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, spoolSegmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, spoolSegment{seq: seq})
+	}
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].seq < s.segments[j].seq
+	})
+
+	for i := range s.segments {
+		if err := s.scanSegment(&s.segments[i]); err != nil {
+			return err
+		}
+	}
+
+	if len(s.segments) == 0 {
+		s.segments = append(s.segments, spoolSegment{seq: 1})
+	}
+	s.read = spoolPosition{seq: s.segments[0].seq}
+	s.loadCursor()
+
+	active := s.segments[len(s.segments)-1]
+	file, err := os.OpenFile(s.segmentPath(active.seq), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to open log spool segment")
+	}
+	s.active = file
+	return nil
+}
+
+// scanSegment counts the records in a segment and truncates a torn tail
+func (s *Spool) scanSegment(segment *spoolSegment) error {
+	path := s.segmentPath(segment.seq)
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to open log spool segment")
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		record, err := readSpoolFrame(reader)
+		if err != nil {
+			break
+		}
+		offset += spoolFrameHeaderSize + int64(len(record))
+		segment.records++
+	}
+	segment.size = offset
+
+	if info, err := file.Stat(); err == nil && info.Size() > offset {
+		if err := os.Truncate(path, offset); err != nil {
+			return errors.WrapSentinel(err, "failed to truncate torn log spool segment")
+		}
+	}
+	return nil
+}
+
+// loadCursor restores the saved read position, if it is still valid
+func (s *Spool) loadCursor() {
+	data, err := os.ReadFile(filepath.Join(s.opts.Dir, spoolCursorName))
+	if err != nil {
+		return
+	}
+
+	var cursor spoolPosition
+	if _, err = fmt.Sscan(string(data), &cursor.seq, &cursor.offset, &cursor.records); err != nil {
+		return
+	}
+
+	for _, segment := range s.segments {
+		if segment.seq == cursor.seq && cursor.offset <= segment.size && cursor.records <= segment.records {
+			s.read = cursor
+			return
+		}
+	}
+}
+
+func (s *Spool) saveCursor() error {
+	path := filepath.Join(s.opts.Dir, spoolCursorName)
+	data := fmt.Sprintf("%d %d %d\n", s.read.seq, s.read.offset, s.read.records)
+	if err := os.WriteFile(path+".tmp", []byte(data), 0o644); err != nil {
+		return errors.WrapSentinel(err, "failed to save log spool cursor")
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return errors.WrapSentinel(err, "failed to save log spool cursor")
+	}
+	return nil
+}
+
+func (s *Spool) segmentPath(seq uint64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%020d%s", seq, spoolSegmentSuffix))
+}
+
+// Append writes records to the spool.  They are synced to disk once
+// SyncSize bytes await syncing or SyncInterval has passed, so that callers
+// logging each record do not wait for the disk.
+func (s *Spool) Append(records ...[]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return errSpoolClosed
+	}
+
+	for _, record := range records {
+		active := &s.segments[len(s.segments)-1]
+		frameSize := spoolFrameHeaderSize + int64(len(record))
+		if active.size > 0 && active.size+frameSize > s.opts.MaxSegmentSize {
+			if err := s.startSegmentLocked(); err != nil {
+				return err
+			}
+			active = &s.segments[len(s.segments)-1]
+		}
+
+		if _, err := s.active.Write(appendSpoolFrame(nil, record)); err != nil {
+			return errors.WrapSentinel(err, "failed to append to log spool")
+		}
+		active.size += frameSize
+		active.records++
+		s.unsynced += frameSize
+	}
+
+	s.enforceMaxSizeLocked()
+
+	if s.opts.SyncInterval < 0 || s.unsynced >= s.opts.SyncSize {
+		return s.syncLocked()
+	}
+	if s.syncer == nil && s.unsynced > 0 {
+		s.syncer = time.AfterFunc(s.opts.SyncInterval, s.syncDeferred)
+	}
+	return nil
+}
+
+// Sync commits appended records to disk.
+func (s *Spool) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return nil
+	}
+	return s.syncLocked()
+}
+
+func (s *Spool) syncDeferred() {
+	if err := s.Sync(); err != nil {
+		LoggingLogger().Error("Failed to sync log spool",
+			"dir", s.opts.Dir,
+			ErrorKey, err)
+	}
+}
+
+func (s *Spool) syncLocked() error {
+	if s.syncer != nil {
+		s.syncer.Stop()
+		s.syncer = nil
+	}
+	if s.unsynced == 0 {
+		return nil
+	}
+	s.unsynced = 0
+	if err := s.active.Sync(); err != nil {
+		return errors.WrapSentinel(err, "failed to sync log spool")
+	}
+	return nil
+}
+
+func (s *Spool) startSegmentLocked() error {
+	if err := s.syncLocked(); err != nil {
+		return err
+	}
+	if err := s.active.Close(); err != nil {
+		return errors.WrapSentinel(err, "failed to close log spool segment")
+	}
+
+	seq := s.segments[len(s.segments)-1].seq + 1
+	file, err := os.OpenFile(s.segmentPath(seq), os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to create log spool segment")
+	}
+	s.active = file
+	s.segments = append(s.segments, spoolSegment{seq: seq})
+	return nil
+}
+
+// enforceMaxSizeLocked drops the oldest segments beyond MaxSize
+func (s *Spool) enforceMaxSizeLocked() {
+	var size int64
+	for _, segment := range s.segments {
+		size += segment.size
+	}
+
+	for size > s.opts.MaxSize && len(s.segments) > 1 {
+		oldest := s.segments[0]
+		s.segments = s.segments[1:]
+		size -= oldest.size
+
+		dropped := oldest.records
+		if oldest.seq == s.read.seq {
+			dropped -= s.read.records
+		}
+		if oldest.seq >= s.read.seq {
+			s.dropped += uint64(dropped)
+			s.read = spoolPosition{seq: s.segments[0].seq}
+			s.peeked = nil
+		}
+
+		if err := os.Remove(s.segmentPath(oldest.seq)); err != nil {
+			LoggingLogger().Error("Failed to remove log spool segment",
+				"dir", s.opts.Dir,
+				ErrorKey, err)
+		}
+	}
+}
+
+// Peek returns up to limit of the oldest records without consuming them.
+func (s *Spool) Peek(limit int) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.peeked = s.peeked[:0]
+	var result [][]byte
+	position := s.read
+
+	for _, segment := range s.segments {
+		if segment.seq < position.seq {
+			continue
+		}
+		if segment.seq > position.seq {
+			position = spoolPosition{seq: segment.seq}
+		}
+		if position.records >= segment.records {
+			continue
+		}
+
+		records, positions, err := s.readSegment(position, segment, limit-len(result))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, records...)
+		s.peeked = append(s.peeked, positions...)
+
+		if len(result) >= limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// readSegment reads up to limit records from a segment, starting at position
+func (s *Spool) readSegment(position spoolPosition, segment spoolSegment, limit int) ([][]byte, []spoolPosition, error) {
+	file, err := os.Open(s.segmentPath(segment.seq))
+	if err != nil {
+		return nil, nil, errors.WrapSentinel(err, "failed to open log spool segment")
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(io.NewSectionReader(file, position.offset, segment.size-position.offset))
+
+	var records [][]byte
+	var positions []spoolPosition
+	for len(records) < limit && position.records < segment.records {
+		record, err := readSpoolFrame(reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		position.offset += spoolFrameHeaderSize + int64(len(record))
+		position.records++
+		records = append(records, record)
+		positions = append(positions, position)
+	}
+	return records, positions, nil
+}
+
+// Commit consumes the first n records returned by the last Peek, and saves
+// the read position.
+func (s *Spool) Commit(n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n <= 0 || n > len(s.peeked) {
+		return nil
+	}
+	s.read = s.peeked[n-1]
+	s.peeked = s.peeked[:0]
+
+	// remove consumed segments, except the one being written
+	for len(s.segments) > 1 {
+		consumed := s.segments[0]
+		if consumed.seq == s.read.seq && s.read.records >= consumed.records {
+			s.read = spoolPosition{seq: s.segments[1].seq}
+		} else if consumed.seq >= s.read.seq {
+			break
+		}
+		s.segments = s.segments[1:]
+		if err := os.Remove(s.segmentPath(consumed.seq)); err != nil && !os.IsNotExist(err) {
+			return errors.WrapSentinel(err, "failed to remove consumed log spool segment")
+		}
+	}
+
+	return s.saveCursor()
+}
+
+// Stats returns the spool backlog.
+func (s *Spool) Stats() SpoolStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := SpoolStats{Dropped: s.dropped}
+	for _, segment := range s.segments {
+		switch {
+		case segment.seq < s.read.seq:
+			continue
+		case segment.seq == s.read.seq:
+			stats.Records += segment.records - s.read.records
+			stats.Bytes += segment.size - s.read.offset
+		default:
+			stats.Records += segment.records
+			stats.Bytes += segment.size
+		}
+		if segment.records > 0 && (segment.seq != s.read.seq || s.read.records < segment.records) {
+			stats.Segments++
+		}
+	}
+	return stats
+}
+
+// Close closes the segment being written.  Unconsumed records remain on disk.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return nil
+	}
+	s.unregister()
+	err := errors.Join(s.syncLocked(), s.active.Close())
+	s.active = nil
+	return err
+}
+
+func appendSpoolFrame(buf []byte, record []byte) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(record)))
+	buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(record))
+	return append(buf, record...)
+}
+
+func readSpoolFrame(r io.Reader) ([]byte, error) {
+	var header [spoolFrameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, errors.WrapSentinel(err, "failed to read log spool record")
+	}
+
+	size := binary.BigEndian.Uint32(header[:4])
+	if size > spoolMaxRecordSize {
+		return nil, errors.NewCodeError(errors.CodeInternal, nil, "invalid log spool record size %d", size)
+	}
+
+	record := make([]byte, size)
+	if _, err := io.ReadFull(r, record); err != nil {
+		return nil, errors.WrapSentinel(err, "failed to read log spool record")
+	}
+	if crc32.ChecksumIEEE(record) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, errors.NewCodeError(errors.CodeInternal, nil, "invalid log spool record checksum")
+	}
+	return record, nil
+}
+
+// SpoolHandler wraps a remote handler, spooling records while the handler
+// fails and replaying them in order once it recovers.  Records are also
+// spooled while older records await replay, to preserve their order.
+//
+// Only failures returned by Handle are seen, so handlers delivering records
+// in the background, such as HTTPShipper and OTLPHandler, gain nothing from
+// it.  They spool undelivered batches themselves through
+// HTTPShipperOptions.Spool.
+type SpoolHandler struct {
+	state  *spoolHandlerState
+	leaf   slog.Handler // next with attrs and groups applied
+	attrs  []slog.Attr
+	groups []string
+}
+
+type spoolHandlerState struct {
+	next       slog.Handler
+	spool      *Spool
+	wake       chan struct{}
+	ctx        context.Context
+	cancel     context.CancelFunc
+	stopped    chan struct{}
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	unregister func()
+}
+
+// spoolReplayBatchSize is the most records replayed per read of the spool
+const spoolReplayBatchSize = 100
+
+func NewSpoolHandler(next slog.Handler, spool *Spool) *SpoolHandler {
+	ctx, cancel := context.WithCancel(context.Background())
+	state := &spoolHandlerState{
+		next:       next,
+		spool:      spool,
+		wake:       make(chan struct{}, 1),
+		ctx:        ctx,
+		cancel:     cancel,
+		stopped:    make(chan struct{}),
+		minBackoff: time.Second,
+		maxBackoff: time.Minute,
+	}
+	go state.run()
+	state.signal() // replay records spooled by an earlier run
+
+	h := &SpoolHandler{state: state, leaf: next}
+	state.unregister = RegisterLifecycle(h)
+	return h
+}
+
+// NewSpoolHandlerFactory returns a factory for RegisterRemoteHandlerFactory
+// spooling the records of the handler returned by factory.  As with
+// SpoolHandler, records are only spooled when that handler returns an error.
+func NewSpoolHandlerFactory(factory RemoteHandlerFactory, spool *Spool) RemoteHandlerFactory {
+	h := NewSpoolHandler(factory(), spool)
+	return func() slog.Handler { return h }
+}
+
+func (h *SpoolHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.leaf.Enabled(ctx, level)
+}
+
+func (h *SpoolHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.leaf = h.leaf.WithGroup(name)
+	h2.groups = AddGroup(h.groups, name)
+	return &h2
+}
+
+func (h *SpoolHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.leaf = h.leaf.WithAttrs(attrs)
+	h2.attrs = SetAttrsAtPath(h.attrs, h.groups, attrs)
+	return &h2
+}
+
+func (h *SpoolHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.state.spool.Stats().Records == 0 {
+		if err := h.leaf.Handle(ctx, r); err == nil {
+			return nil
+		}
+	}
+
+	recordAttrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		recordAttrs = append(recordAttrs, a)
+		return true
+	})
+
+	data, err := json.Marshal(spooledRecord{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   newSpooledAttrs(SetAttrsAtPath(h.attrs, h.groups, recordAttrs)),
+	})
+	if err != nil {
+		return errors.WrapSentinel(err, "failed to encode spooled record")
+	}
+
+	if err = h.state.spool.Append(data); err != nil {
+		return err
+	}
+	h.state.signal()
+	return nil
+}
+
+// Flush starts replaying spooled records.  Records are stored on disk, so
+// it does not wait for the replay.
+func (h *SpoolHandler) Flush(context.Context) error {
+	h.state.signal()
+	return nil
+}
+
+// Close stops replaying records.  Unreplayed records remain in the spool.
+func (h *SpoolHandler) Close(context.Context) error {
+	h.state.unregister()
+	h.state.cancel()
+	<-h.state.stopped
+	return nil
+}
+
+func (s *spoolHandlerState) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *spoolHandlerState) run() {
+	defer close(s.stopped)
+
+	backoff := s.minBackoff
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.wake:
+		case <-retry:
+		}
+
+		retry = nil
+		if err := s.replay(); err != nil {
+			retry = time.After(backoff)
+			backoff = min(backoff*2, s.maxBackoff)
+			continue
+		}
+		backoff = s.minBackoff
+	}
+}
+
+// replay delivers spooled records until the spool is empty or delivery fails
+func (s *spoolHandlerState) replay() error {
+	for s.ctx.Err() == nil {
+		records, err := s.spool.Peek(spoolReplayBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		for i, data := range records {
+			if err = s.replayRecord(data); err != nil {
+				_ = s.spool.Commit(i)
+				return err
+			}
+		}
+		if err = s.spool.Commit(len(records)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *spoolHandlerState) replayRecord(data []byte) error {
+	var spooled spooledRecord
+	if err := json.Unmarshal(data, &spooled); err != nil {
+		LoggingLogger().Error("Discarding unreadable spooled log record",
+			ErrorKey, err)
+		return nil
+	}
+
+	r := slog.NewRecord(spooled.Time, spooled.Level, spooled.Message, 0)
+	r.AddAttrs(spooledAttrsValues(spooled.Attrs)...)
+	return s.next.Handle(s.ctx, r)
+}
+
+// spooledRecord is the spool encoding of a record and its handler attributes
+type spooledRecord struct {
+	Time    time.Time     `json:"time"`
+	Level   slog.Level    `json:"level"`
+	Message string        `json:"msg"`
+	Attrs   []spooledAttr `json:"attrs,omitempty"`
+}
+
+// spooledAttr encodes an attribute, preserving the kind of its value
+type spooledAttr struct {
+	Key      string         `json:"k"`
+	String   *string        `json:"s,omitempty"`
+	Int64    *int64         `json:"i,omitempty"`
+	Uint64   *uint64        `json:"u,omitempty"`
+	Float64  *jsonFloat64   `json:"f,omitempty"`
+	Bool     *bool          `json:"b,omitempty"`
+	Duration *time.Duration `json:"d,omitempty"`
+	Time     *time.Time     `json:"t,omitempty"`
+	Group    []spooledAttr  `json:"g,omitempty"`
+}
+
+func newSpooledAttrs(attrs []slog.Attr) []spooledAttr {
+	result := make([]spooledAttr, 0, len(attrs))
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		spooled := spooledAttr{Key: attr.Key}
+		switch value.Kind() {
+		case slog.KindInt64:
+			spooled.Int64 = lo.ToPtr(value.Int64())
+		case slog.KindUint64:
+			spooled.Uint64 = lo.ToPtr(value.Uint64())
+		case slog.KindFloat64:
+			spooled.Float64 = lo.ToPtr(jsonFloat64(value.Float64()))
+		case slog.KindBool:
+			spooled.Bool = lo.ToPtr(value.Bool())
+		case slog.KindDuration:
+			spooled.Duration = lo.ToPtr(value.Duration())
+		case slog.KindTime:
+			spooled.Time = lo.ToPtr(value.Time())
+		case slog.KindGroup:
+			spooled.Group = newSpooledAttrs(value.Group())
+		default:
+			spooled.String = lo.ToPtr(value.String())
+		}
+		result = append(result, spooled)
+	}
+	return result
+}
+
+func spooledAttrsValues(attrs []spooledAttr) []slog.Attr {
+	result := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		var value slog.Value
+		switch {
+		case attr.String != nil:
+			value = slog.StringValue(*attr.String)
+		case attr.Int64 != nil:
+			value = slog.Int64Value(*attr.Int64)
+		case attr.Uint64 != nil:
+			value = slog.Uint64Value(*attr.Uint64)
+		case attr.Float64 != nil:
+			value = slog.Float64Value(float64(*attr.Float64))
+		case attr.Bool != nil:
+			value = slog.BoolValue(*attr.Bool)
+		case attr.Duration != nil:
+			value = slog.DurationValue(*attr.Duration)
+		case attr.Time != nil:
+			value = slog.TimeValue(*attr.Time)
+		default:
+			value = slog.GroupValue(spooledAttrsValues(attr.Group)...)
+		}
+		result = append(result, slog.Attr{Key: attr.Key, Value: value})
+	}
+	return result
+}
@@ -0,0 +1,360 @@
+package log
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+func peekSpool(t *testing.T, s *Spool, limit int) []string {
+	records, err := s.Peek(limit)
+	assert.NoError(t, err)
+	var result []string
+	for _, record := range records {
+		result = append(result, string(record))
+	}
+	return result
+}
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenSpool(SpoolOptions{Dir: dir, MaxSegmentSize: 25})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Append([]byte("one"), []byte("two"), []byte("three")))
+	assert.NoError(t, s.Append([]byte("four")))
+	assert.Equal(t, SpoolStats{Segments: 2, Records: 4, Bytes: 4*8 + 15}, s.Stats())
+
+	assert.Equal(t, []string{"one", "two", "three"}, peekSpool(t, s, 3))
+	assert.NoError(t, s.Commit(2))
+	assert.Equal(t, 2, s.Stats().Records)
+	assert.NoError(t, s.Close())
+
+	// survives a restart
+	s, err = OpenSpool(SpoolOptions{Dir: dir, MaxSegmentSize: 25})
+	assert.NoError(t, err)
+	defer s.Close()
+	assert.Equal(t, []string{"three", "four"}, peekSpool(t, s, 10))
+	assert.NoError(t, s.Commit(2))
+	assert.Empty(t, peekSpool(t, s, 10))
+	assert.Equal(t, SpoolStats{}, s.Stats())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2, "consumed segments removed")
+}
+
+func TestSpool_Sync(t *testing.T) {
+	s, err := OpenSpool(SpoolOptions{Dir: t.TempDir(), SyncInterval: 10 * time.Millisecond, SyncSize: 20})
+	assert.NoError(t, err)
+	defer s.Close()
+
+	unsynced := func() int64 {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.unsynced
+	}
+
+	assert.NoError(t, s.Append([]byte("one")))
+	assert.Equal(t, int64(11), unsynced(), "sync deferred")
+	assert.Eventually(t, func() bool { return unsynced() == 0 }, time.Second, time.Millisecond, "synced after interval")
+
+	assert.NoError(t, s.Append([]byte("two"), []byte("three")))
+	assert.Zero(t, unsynced(), "synced at size")
+
+	assert.NoError(t, s.Append([]byte("four")))
+	assert.NoError(t, s.Sync())
+	assert.Zero(t, unsynced())
+}
+
+func TestSpool_TornRecord(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenSpool(SpoolOptions{Dir: dir})
+	assert.NoError(t, err)
+	assert.NoError(t, s.Append([]byte("complete")))
+	assert.NoError(t, s.Close())
+
+	// a record interrupted by a crash
+	file, err := os.OpenFile(s.segmentPath(1), os.O_WRONLY|os.O_APPEND, 0)
+	assert.NoError(t, err)
+	_, err = file.Write(appendSpoolFrame(nil, []byte("torn"))[:10])
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	s, err = OpenSpool(SpoolOptions{Dir: dir})
+	assert.NoError(t, err)
+	defer s.Close()
+	assert.NoError(t, s.Append([]byte("after")))
+	assert.Equal(t, []string{"complete", "after"}, peekSpool(t, s, 10))
+}
+
+func TestSpool_MaxSize(t *testing.T) {
+	s, err := OpenSpool(SpoolOptions{Dir: t.TempDir(), MaxSegmentSize: 10, MaxSize: 30})
+	assert.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, s.Append([]byte(fmt.Sprint(i))))
+	}
+	assert.Equal(t, []string{"2", "3", "4"}, peekSpool(t, s, 10))
+	assert.Equal(t, uint64(2), s.Stats().Dropped)
+}
+
+// flakyHandler records messages and their attributes, failing while down
+type flakyHandler struct {
+	mu       sync.Mutex
+	down     bool
+	messages []string
+}
+
+var errFlakyHandlerDown = errors.NewSentinel("handler down")
+
+func (h *flakyHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *flakyHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *flakyHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *flakyHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.down {
+		return errFlakyHandlerDown
+	}
+	message := r.Message
+	r.Attrs(func(a slog.Attr) bool {
+		message += " " + a.String()
+		return true
+	})
+	h.messages = append(h.messages, message)
+	return nil
+}
+
+func (h *flakyHandler) setDown(down bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.down = down
+}
+
+func (h *flakyHandler) snapshot() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.messages...)
+}
+
+func TestSpoolHandler(t *testing.T) {
+	spool, err := OpenSpool(SpoolOptions{Dir: t.TempDir()})
+	assert.NoError(t, err)
+	defer spool.Close()
+
+	next := &flakyHandler{down: true}
+	h := NewSpoolHandler(next, spool)
+	defer h.Close(context.Background())
+
+	logger := slog.New(h).With(LoggerKey, "svc").WithGroup("req")
+	logger.Info("one", "n", 1)
+	logger.Info("two", "elapsed", time.Second)
+	assert.Equal(t, 2, spool.Stats().Records)
+
+	next.setDown(false)
+	logger.Info("three", "ok", true)
+
+	assert.Eventually(t, func() bool {
+		return len(next.snapshot()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{
+		"one logger=svc req=[n=1]",
+		"two logger=svc req=[elapsed=1s]",
+		"three logger=svc req=[ok=true]",
+	}, next.snapshot())
+	assert.Zero(t, spool.Stats().Records)
+}
+
+func TestSpooledAttrs_NonFinite(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.Float64("nan", math.NaN()),
+		slog.Float64("inf", math.Inf(1)),
+		slog.Float64("-inf", math.Inf(-1)),
+		slog.Float64("ratio", 0.5),
+	}
+
+	data, err := json.Marshal(newSpooledAttrs(attrs))
+	assert.NoError(t, err)
+
+	var spooled []spooledAttr
+	assert.NoError(t, json.Unmarshal(data, &spooled))
+	decoded := spooledAttrsValues(spooled)
+	if assert.Len(t, decoded, 4) {
+		assert.True(t, math.IsNaN(decoded[0].Value.Float64()))
+		assert.Equal(t, math.Inf(1), decoded[1].Value.Float64())
+		assert.Equal(t, math.Inf(-1), decoded[2].Value.Float64())
+		assert.Equal(t, 0.5, decoded[3].Value.Float64())
+	}
+}
+
+func TestHTTPShipper_Spool(t *testing.T) {
+	spool, err := OpenSpool(SpoolOptions{Dir: t.TempDir()})
+	assert.NoError(t, err)
+	defer spool.Close()
+
+	collector := &shipperCollector{
+		statuses: []int{http.StatusBadGateway, http.StatusBadGateway},
+	}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	s, err := NewHTTPShipper(HTTPShipperOptions{
+		URL:        server.URL,
+		MaxRetries: 1,
+		MinBackoff: time.Millisecond,
+		Spool:      spool,
+	})
+	assert.NoError(t, err)
+	defer s.Close(context.Background())
+
+	logger := slog.New(s.Handler())
+	logger.Info("spooled")
+	assert.NoError(t, s.Flush(context.Background()))
+	assert.Equal(t, 1, spool.Stats().Records)
+
+	time.Sleep(10 * time.Millisecond) // wait out the replay backoff
+	logger.Info("queued")
+	assert.NoError(t, s.Flush(context.Background()))
+
+	_, lines := collector.snapshot()
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], `"msg":"spooled"`)
+		assert.Contains(t, lines[1], `"msg":"queued"`)
+	}
+	assert.Zero(t, spool.Stats().Records)
+	assert.Zero(t, s.Dropped())
+}
+
+func TestNewLogger_RemoteSpool(t *testing.T) {
+	resetLoggerLevels(t)
+	defer RegisterRemoteHandlerFactory(nil)
+
+	spool, err := OpenSpool(SpoolOptions{Dir: t.TempDir()})
+	assert.NoError(t, err)
+	defer spool.Close()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	s, err := NewHTTPShipper(HTTPShipperOptions{
+		URL:        server.URL,
+		MinBackoff: time.Hour,
+		Spool:      spool,
+	})
+	assert.NoError(t, err)
+	defer s.Close(context.Background())
+
+	handler := s.Handler()
+	RegisterRemoteHandlerFactory(func() slog.Handler { return handler })
+
+	logger := NewLogger("svc.remote")
+	logger.Info("one")
+	logger.Info("two")
+	assert.NoError(t, s.Flush(context.Background()))
+
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Equal(t, 2, spool.Stats().Records, "unauthorized records spooled")
+	assert.Zero(t, s.Dropped())
+
+	records, err := spool.Peek(10)
+	assert.NoError(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Contains(t, string(records[0]), `"logger":"svc.remote"`)
+	}
+}
+
+func TestHTTPShipper_SpoolPoison(t *testing.T) {
+	spool, err := OpenSpool(SpoolOptions{Dir: t.TempDir()})
+	assert.NoError(t, err)
+	defer spool.Close()
+
+	var mu sync.Mutex
+	var requests int
+	var lines []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zr, _ := gzip.NewReader(r.Body)
+		body, _ := io.ReadAll(zr)
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if strings.Contains(string(body), "poison") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		lines = append(lines, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+	}))
+	defer server.Close()
+
+	s, err := NewHTTPShipper(HTTPShipperOptions{
+		URL:   server.URL,
+		Spool: spool,
+	})
+	assert.NoError(t, err)
+	defer s.Close(context.Background())
+
+	logger := slog.New(s.Handler())
+	for _, msg := range []string{"one", "two", "poison", "three"} {
+		logger.Info(msg)
+	}
+	assert.NoError(t, s.Flush(context.Background()))
+	assert.NoError(t, s.Flush(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, lines, 3, "records beside the poison record delivered")
+	assert.Equal(t, 5, requests, "batch bisected to the poison record")
+	assert.Zero(t, spool.Stats().Records, "rejected records not spooled")
+	assert.Equal(t, uint64(1), s.Dropped())
+}
+
+func TestHTTPShipper_SpoolReplayBackoff(t *testing.T) {
+	spool, err := OpenSpool(SpoolOptions{Dir: t.TempDir()})
+	assert.NoError(t, err)
+	defer spool.Close()
+	assert.NoError(t, spool.Append([]byte("{}\n")))
+
+	collector := &shipperCollector{
+		statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+	}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	s, err := NewHTTPShipper(HTTPShipperOptions{
+		URL:        server.URL,
+		MinBackoff: time.Hour,
+		Spool:      spool,
+	})
+	assert.NoError(t, err)
+	defer s.Close(context.Background())
+
+	assert.NoError(t, s.Flush(context.Background()))
+	assert.NoError(t, s.Flush(context.Background()))
+
+	requests, _ := collector.snapshot()
+	assert.Equal(t, 1, requests, "replay waits out the backoff")
+	assert.Equal(t, 1, spool.Stats().Records)
+}
@@ -17,6 +17,10 @@ const (
 
 	LoggerKey    = "logger"
 	OperationKey = "operation"
+
+	TraceIDKey    = "trace_id"
+	SpanIDKey     = "span_id"
+	TraceFlagsKey = "trace_flags"
 )
 
 type Logger interface {
@@ -71,9 +75,17 @@ func (f *formattingLogger) pc() uintptr {
 	return pcs[0]
 }
 
+// enabled honors any level override carried by the context
+func (f *formattingLogger) enabled(ctx context.Context, level slog.Level) bool {
+	if override, ok := levelFromContext(ctx); ok {
+		return level >= override
+	}
+	return f.logger.Enabled(ctx, level)
+}
+
 // internal log any handler
 func (f *formattingLogger) log(ctx context.Context, level slog.Level, msg string, values ...any) {
-	if !f.logger.Enabled(ctx, level) {
+	if !f.enabled(ctx, level) {
 		return
 	}
 
@@ -87,6 +99,7 @@ func (f *formattingLogger) log(ctx context.Context, level slog.Level, msg string
 	if ctx != nil {
 		attrs := logAttrsFromContext(ctx)
 		record.Add(lo.ToAnySlice(attrs)...)
+		record.AddAttrs(traceAttrsFromContext(ctx)...)
 	}
 
 	_ = l.logger.Handler().Handle(ctx, record)
@@ -94,7 +107,7 @@ func (f *formattingLogger) log(ctx context.Context, level slog.Level, msg string
 
 // internal log attr handler
 func (f *formattingLogger) logAttrs(ctx context.Context, level slog.Level, msg string, values ...slog.Attr) {
-	if !f.logger.Enabled(ctx, level) {
+	if !f.enabled(ctx, level) {
 		return
 	}
 
@@ -108,6 +121,7 @@ func (f *formattingLogger) logAttrs(ctx context.Context, level slog.Level, msg s
 	if ctx != nil {
 		attrs := logAttrsFromContext(ctx)
 		record.Add(lo.ToAnySlice(attrs)...)
+		record.AddAttrs(traceAttrsFromContext(ctx)...)
 	}
 
 	_ = l.logger.Handler().Handle(ctx, record)
@@ -0,0 +1,466 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+const (
+	SyslogFormatRFC5424 = "rfc5424"
+	SyslogFormatRFC3164 = "rfc3164"
+	SyslogFormatDefault = SyslogFormatRFC5424
+)
+
+const (
+	SyslogFacilityUser   = 1
+	SyslogFacilityDaemon = 3
+	SyslogFacilityLocal0 = 16
+)
+
+const (
+	syslogSeverityCritical = 2
+	syslogSeverityError    = 3
+	syslogSeverityWarning  = 4
+	syslogSeverityNotice   = 5
+	syslogSeverityInfo     = 6
+	syslogSeverityDebug    = 7
+)
+
+var errSyslogClosed = errors.NewSentinel("syslog handler closed")
+
+const (
+	syslogNilValue = "-"
+	// syslogDefaultSDID uses the documentation enterprise number from RFC 5612
+	syslogDefaultSDID = "slog@32473"
+)
+
+type SyslogOptions struct {
+	// Network is one of udp, tcp, unix or unixgram.  Defaults to udp.
+	Network string
+	// Address of the syslog server.  Defaults to localhost:514.
+	Address string
+	// Format is SyslogFormatRFC5424 or SyslogFormatRFC3164.
+	Format string
+	// Facility defaults to SyslogFacilityUser.
+	Facility int
+	// Hostname defaults to os.Hostname.
+	Hostname string
+	// AppName defaults to the executable name.
+	AppName string
+	// LoggerAsAppName sends the logger name as APP-NAME instead of MSGID.
+	LoggerAsAppName bool
+	// StructuredDataID names the RFC 5424 STRUCTURED-DATA element carrying
+	// attributes.  Defaults to slog@32473.
+	StructuredDataID string
+	// Level defaults to LevelInfo.
+	Level slog.Leveler
+	// Timeout bounds connecting and writing.  Defaults to 5s.
+	Timeout time.Duration
+}
+
+// SyslogHandler sends records to a syslog server.  Attributes and groups
+// are sent as RFC 5424 STRUCTURED-DATA parameters named by their dotted
+// group path, or appended to the message in RFC 3164 format.  While the
+// server is unreachable, records fail without waiting between reconnect
+// attempts, which back off from 500ms to 30s.
+type SyslogHandler struct {
+	opts       SyslogOptions
+	attrs      []slog.Attr
+	groups     []string
+	conn       *syslogConn
+	unregister func()
+}
+
+func NewSyslogHandler(opts SyslogOptions) (*SyslogHandler, error) {
+	if opts.Network == "" {
+		opts.Network = "udp"
+	}
+	if opts.Address == "" {
+		opts.Address = "localhost:514"
+	}
+	if opts.Format == "" {
+		opts.Format = SyslogFormatDefault
+	}
+	if opts.Facility == 0 {
+		opts.Facility = SyslogFacilityUser
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	if opts.AppName == "" {
+		opts.AppName = filepath.Base(os.Args[0])
+	}
+	if opts.StructuredDataID == "" {
+		opts.StructuredDataID = syslogDefaultSDID
+	}
+	if opts.Level == nil {
+		opts.Level = LevelInfo
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	switch opts.Network {
+	case "udp", "udp4", "udp6", "unixgram", "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return nil, errors.NewCodeError(errors.CodeInvalidArgument, nil, "unknown syslog network %q", opts.Network)
+	}
+
+	switch opts.Format {
+	case SyslogFormatRFC5424, SyslogFormatRFC3164:
+	default:
+		return nil, errors.NewCodeError(errors.CodeInvalidArgument, nil, "unknown syslog format %q", opts.Format)
+	}
+
+	h := &SyslogHandler{
+		opts: opts,
+		conn: &syslogConn{
+			network: opts.Network,
+			address: opts.Address,
+			timeout: opts.Timeout,
+			mu:      &sync.Mutex{},
+		},
+	}
+	h.unregister = RegisterLifecycle(h)
+	return h, nil
+}
+
+// NewSyslogHandlerFactory returns a factory for RegisterRemoteHandlerFactory
+// sharing one syslog connection.
+func NewSyslogHandlerFactory(opts SyslogOptions) (RemoteHandlerFactory, error) {
+	h, err := NewSyslogHandler(opts)
+	if err != nil {
+		return nil, err
+	}
+	return func() slog.Handler { return h }, nil
+}
+
+func (h *SyslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if override, ok := levelFromContext(ctx); ok {
+		return level >= override
+	}
+	return level >= h.opts.Level.Level()
+}
+
+func (h *SyslogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = AddGroup(h.groups, name)
+	return &h2
+}
+
+func (h *SyslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.attrs = SetAttrsAtPath(h.attrs, h.groups, attrs)
+	return &h2
+}
+
+func (h *SyslogHandler) Handle(_ context.Context, r slog.Record) error {
+	recordAttrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		recordAttrs = append(recordAttrs, a)
+		return true
+	})
+	attrs := SetAttrsAtPath(h.attrs, h.groups, recordAttrs)
+
+	var loggerName string
+	var params [][2]string
+	walkAttrs(nil, attrs, func(path []string, value slog.Value) {
+		if len(path) == 1 && path[0] == LoggerKey {
+			loggerName = value.String()
+			return
+		}
+		params = append(params, [2]string{strings.Join(path, "."), syslogValueString(value)})
+	})
+
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	bufp := allocBuf()
+	buf := *bufp
+	defer func() {
+		*bufp = buf
+		freeBuf(bufp)
+	}()
+
+	if h.opts.Format == SyslogFormatRFC3164 {
+		buf = h.append3164(buf, r, t, loggerName, params)
+	} else {
+		buf = h.append5424(buf, r, t, loggerName, params)
+	}
+
+	return h.conn.write(buf)
+}
+
+// Flush does nothing, since records are sent as they are handled.
+func (h *SyslogHandler) Flush(context.Context) error {
+	return nil
+}
+
+// Close closes the connection to the syslog server, shared by every handler
+// derived from h.  Records handled afterwards fail.
+func (h *SyslogHandler) Close(context.Context) error {
+	h.unregister()
+	return h.conn.close()
+}
+
+func (h *SyslogHandler) priority(level slog.Level) int {
+	return h.opts.Facility*8 + syslogSeverity(level)
+}
+
+func (h *SyslogHandler) appName(loggerName string) string {
+	if h.opts.LoggerAsAppName && loggerName != "" {
+		return loggerName
+	}
+	return h.opts.AppName
+}
+
+// append5424 formats `<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG`
+func (h *SyslogHandler) append5424(buf []byte, r slog.Record, t time.Time, loggerName string, params [][2]string) []byte {
+	msgID := loggerName
+	if h.opts.LoggerAsAppName {
+		msgID = ""
+	}
+
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(h.priority(r.Level)), 10)
+	buf = append(buf, ">1 "...)
+	buf = t.AppendFormat(buf, FormatTimestampMicro)
+	buf = append(buf, ' ')
+	buf = appendSyslogHeaderField(buf, h.opts.Hostname, 255)
+	buf = append(buf, ' ')
+	buf = appendSyslogHeaderField(buf, h.appName(loggerName), 48)
+	buf = append(buf, ' ')
+	buf = strconv.AppendInt(buf, int64(os.Getpid()), 10)
+	buf = append(buf, ' ')
+	buf = appendSyslogHeaderField(buf, msgID, 32)
+	buf = append(buf, ' ')
+
+	if len(params) == 0 {
+		buf = append(buf, syslogNilValue...)
+	} else {
+		buf = append(buf, '[')
+		buf = appendSyslogSDName(buf, h.opts.StructuredDataID, 32)
+		for _, param := range params {
+			buf = append(buf, ' ')
+			buf = appendSyslogSDName(buf, param[0], 32)
+			buf = append(buf, `="`...)
+			buf = appendSyslogSDValue(buf, param[1])
+			buf = append(buf, '"')
+		}
+		buf = append(buf, ']')
+	}
+
+	if r.Message != "" {
+		buf = append(buf, ' ')
+		buf = append(buf, r.Message...)
+	}
+	return buf
+}
+
+// append3164 formats `<PRI>TIMESTAMP HOSTNAME TAG[PID]: MSG key=value...`
+func (h *SyslogHandler) append3164(buf []byte, r slog.Record, t time.Time, loggerName string, params [][2]string) []byte {
+	if loggerName != "" && !h.opts.LoggerAsAppName {
+		params = append([][2]string{{LoggerKey, loggerName}}, params...)
+	}
+
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(h.priority(r.Level)), 10)
+	buf = append(buf, '>')
+	buf = t.AppendFormat(buf, time.Stamp)
+	buf = append(buf, ' ')
+	buf = appendSyslogHeaderField(buf, h.opts.Hostname, 255)
+	buf = append(buf, ' ')
+	buf = appendSyslogHeaderField(buf, h.appName(loggerName), 32)
+	buf = append(buf, '[')
+	buf = strconv.AppendInt(buf, int64(os.Getpid()), 10)
+	buf = append(buf, "]: "...)
+	buf = append(buf, r.Message...)
+
+	for _, param := range params {
+		buf = append(buf, ' ')
+		buf = append(buf, param[0]...)
+		buf = append(buf, '=')
+		if strings.ContainsAny(param[1], " \"=\n") || param[1] == "" {
+			buf = strconv.AppendQuote(buf, param[1])
+		} else {
+			buf = append(buf, param[1]...)
+		}
+	}
+	return buf
+}
+
+// syslogSeverity maps a slog level to a syslog severity
+func syslogSeverity(level slog.Level) int {
+	switch {
+	case level >= LevelError+4:
+		return syslogSeverityCritical
+	case level >= LevelError:
+		return syslogSeverityError
+	case level >= LevelWarn:
+		return syslogSeverityWarning
+	case level > LevelInfo:
+		return syslogSeverityNotice
+	case level >= LevelInfo:
+		return syslogSeverityInfo
+	default:
+		return syslogSeverityDebug
+	}
+}
+
+func syslogValueString(value slog.Value) string {
+	if value.Kind() == slog.KindTime {
+		return value.Time().Format(time.RFC3339Nano)
+	}
+	return value.String()
+}
+
+// appendSyslogHeaderField appends printable ASCII, or the nil value if empty
+func appendSyslogHeaderField(buf []byte, s string, maxLen int) []byte {
+	if s == "" {
+		return append(buf, syslogNilValue...)
+	}
+	for i := 0; i < len(s) && i < maxLen; i++ {
+		c := s[i]
+		if c < 33 || c > 126 {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendSyslogSDName appends an SD-NAME, which excludes '=', ' ', ']' and '"'
+func appendSyslogSDName(buf []byte, s string, maxLen int) []byte {
+	for i := 0; i < len(s) && i < maxLen; i++ {
+		c := s[i]
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendSyslogSDValue appends a PARAM-VALUE, escaping '"', '\' and ']'
+func appendSyslogSDValue(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\\', ']':
+			buf = append(buf, '\\')
+		}
+		buf = append(buf, s[i])
+	}
+	return buf
+}
+
+// syslogConn is a syslog connection, redialed after a failed write.  After
+// a failed dial, writes fail fast until the reconnect backoff has passed.
+type syslogConn struct {
+	network string
+	address string
+	timeout time.Duration
+	mu      *sync.Mutex
+	conn    net.Conn
+	closed  bool
+	dialErr error     // the last failed dial
+	dialAt  time.Time // the earliest next dial
+	backoff time.Duration
+}
+
+const (
+	syslogMinBackoff = 500 * time.Millisecond
+	syslogMaxBackoff = 30 * time.Second
+)
+
+func (c *syslogConn) write(msg []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errors.WrapSentinel(errSyslogClosed, c.address)
+	}
+
+	if c.stream() {
+		// RFC 6587 octet counting
+		framed := strconv.AppendInt(make([]byte, 0, len(msg)+8), int64(len(msg)), 10)
+		framed = append(framed, ' ')
+		msg = append(framed, msg...)
+	}
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if c.conn == nil {
+			if err = c.dial(); err != nil {
+				return err
+			}
+		}
+
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+		if _, err = c.conn.Write(msg); err == nil {
+			return nil
+		}
+
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+	return errors.WrapSentinel(err, "failed to send syslog message")
+}
+
+// dial connects to the server, unless waiting out the backoff after a
+// failed dial
+func (c *syslogConn) dial() error {
+	if c.dialErr != nil && time.Now().Before(c.dialAt) {
+		return errors.WrapSentinel(c.dialErr, "failed to connect to syslog")
+	}
+
+	conn, err := net.DialTimeout(c.network, c.address, c.timeout)
+	if err != nil {
+		c.backoff = min(max(c.backoff*2, syslogMinBackoff), syslogMaxBackoff)
+		c.dialErr = err
+		c.dialAt = time.Now().Add(c.backoff)
+		return errors.WrapSentinel(err, "failed to connect to syslog")
+	}
+
+	c.conn = conn
+	c.dialErr = nil
+	c.backoff = 0
+	return nil
+}
+
+func (c *syslogConn) stream() bool {
+	switch c.network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return true
+	}
+	return false
+}
+
+func (c *syslogConn) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
@@ -0,0 +1,200 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogSeverity(t *testing.T) {
+	assert.Equal(t, syslogSeverityDebug, syslogSeverity(LevelTrace))
+	assert.Equal(t, syslogSeverityDebug, syslogSeverity(LevelDebug))
+	assert.Equal(t, syslogSeverityInfo, syslogSeverity(LevelInfo))
+	assert.Equal(t, syslogSeverityNotice, syslogSeverity(LevelInfo+2))
+	assert.Equal(t, syslogSeverityWarning, syslogSeverity(LevelWarn))
+	assert.Equal(t, syslogSeverityError, syslogSeverity(LevelError))
+	assert.Equal(t, syslogSeverityCritical, syslogSeverity(LevelError+4))
+}
+
+func TestSyslogHandler_UDP(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer server.Close()
+
+	factory, err := NewSyslogHandlerFactory(SyslogOptions{
+		Network:  "udp",
+		Address:  server.LocalAddr().String(),
+		Facility: SyslogFacilityLocal0,
+		Hostname: "host",
+		AppName:  "app",
+		Level:    LevelDebug,
+	})
+	assert.NoError(t, err)
+	defer factory().(*SyslogHandler).Close(context.Background())
+
+	logger := slog.New(factory()).With(LoggerKey, "svc.db").WithGroup("query")
+	logger.Warn("slow query", "sql", `select "x"]`, "ms", 1500)
+
+	packet := make([]byte, 4096)
+	_ = server.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := server.ReadFrom(packet)
+	assert.NoError(t, err)
+
+	pattern := regexp.MustCompile(`^<132>1 \S+ host app \d+ svc\.db ` +
+		regexp.QuoteMeta(`[slog@32473 query.sql="select \"x\"\]" query.ms="1500"] slow query`) + `$`)
+	assert.Regexp(t, pattern, string(packet[:n]))
+}
+
+func TestSyslogHandler_TCPReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	messages := make(chan string, 16)
+	go func() {
+		for first := true; ; first = false {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			reader := bufio.NewReader(conn)
+			for {
+				length, err := reader.ReadString(' ')
+				if err != nil {
+					break
+				}
+				n, _ := strconv.Atoi(strings.TrimSpace(length))
+				msg := make([]byte, n)
+				if _, err = io.ReadFull(reader, msg); err != nil {
+					break
+				}
+				messages <- string(msg)
+				if first {
+					// drop the first connection after one message
+					break
+				}
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	h, err := NewSyslogHandler(SyslogOptions{
+		Network:         "tcp",
+		Address:         listener.Addr().String(),
+		LoggerAsAppName: true,
+	})
+	assert.NoError(t, err)
+	defer h.Close(context.Background())
+	logger := slog.New(h).With(LoggerKey, "svc.api")
+
+	logger.Info("first")
+	assert.Regexp(t, `^<14>1 \S+ \S+ svc\.api \d+ - - first$`, <-messages)
+
+	deadline := time.After(5 * time.Second)
+	for {
+		logger.Info("again")
+		select {
+		case msg := <-messages:
+			assert.Contains(t, msg, "again")
+			return
+		case <-deadline:
+			t.Fatal("no message after reconnect")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestSyslogHandler_RFC3164(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	assert.NoError(t, err)
+	defer server.Close()
+
+	h, err := NewSyslogHandler(SyslogOptions{
+		Network:  "unixgram",
+		Address:  path,
+		Format:   SyslogFormatRFC3164,
+		Hostname: "host",
+		AppName:  "app",
+	})
+	assert.NoError(t, err)
+	defer h.Close(context.Background())
+
+	slog.New(h).With(LoggerKey, "svc").ErrorContext(context.Background(), "failed", "reason", "no route")
+
+	packet := make([]byte, 4096)
+	_ = server.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := server.Read(packet)
+	assert.NoError(t, err)
+	assert.Regexp(t,
+		`^<11>\w{3} [ \d]\d \d\d:\d\d:\d\d host app\[`+strconv.Itoa(os.Getpid())+`\]: failed logger=svc reason="no route"$`,
+		string(packet[:n]))
+}
+
+func TestNewLogger_SyslogBackoff(t *testing.T) {
+	resetLoggerLevels(t)
+	defer RegisterRemoteHandlerFactory(nil)
+
+	path := filepath.Join(t.TempDir(), "log.sock")
+	factory, err := NewSyslogHandlerFactory(SyslogOptions{
+		Network: "unixgram",
+		Address: path,
+	})
+	assert.NoError(t, err)
+	h := factory().(*SyslogHandler)
+	defer h.Close(context.Background())
+	RegisterRemoteHandlerFactory(factory)
+
+	logger := NewLogger("svc.syslog")
+	logger.Info("unavailable")
+
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	assert.NoError(t, err)
+	defer server.Close()
+
+	// fails fast until the backoff has passed
+	logger.Info("backing off")
+	packet := make([]byte, 4096)
+	_ = server.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	_, err = server.Read(packet)
+	assert.Error(t, err, "no dial during the backoff")
+
+	h.conn.mu.Lock()
+	h.conn.dialAt = time.Now()
+	h.conn.mu.Unlock()
+
+	logger.Info("reconnected")
+	_ = server.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := server.Read(packet)
+	assert.NoError(t, err)
+	assert.Contains(t, string(packet[:n]), "reconnected")
+}
+
+func TestNewSyslogHandler_Invalid(t *testing.T) {
+	_, err := NewSyslogHandler(SyslogOptions{Network: "sctp"})
+	assert.Error(t, err)
+	_, err = NewSyslogHandler(SyslogOptions{Format: "rfc1"})
+	assert.Error(t, err)
+}
+
+func TestSyslogHandler_Close(t *testing.T) {
+	h, err := NewSyslogHandler(SyslogOptions{Address: "127.0.0.1:9"})
+	assert.NoError(t, err)
+	assert.Contains(t, registeredLifecycles(), Lifecycle(h), "registered")
+
+	assert.NoError(t, h.Close(context.Background()))
+	assert.NotContains(t, registeredLifecycles(), Lifecycle(h), "unregistered")
+	err = h.WithAttrs([]slog.Attr{slog.Int("id", 1)}).Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "dropped", 0))
+	assert.ErrorIs(t, err, errSyslogClosed)
+}
@@ -0,0 +1,141 @@
+package log
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+
+	"code.internetisalie.net/slogan/pkg/errors"
+)
+
+// HeaderTraceparent carries the W3C trace context of a request.
+const HeaderTraceparent = "traceparent"
+
+var errInvalidTraceparent = errors.NewSentinel("invalid traceparent")
+
+// SpanContext identifies the trace and span a record was logged in.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// IsValid reports whether both the trace and span identifiers are set.
+func (s SpanContext) IsValid() bool {
+	return s.TraceID != [16]byte{} && s.SpanID != [8]byte{}
+}
+
+// Sampled reports whether the sampled trace flag is set.
+func (s SpanContext) Sampled() bool {
+	return s.Flags&0x01 != 0
+}
+
+// String formats the span context as a version 00 traceparent header value.
+func (s SpanContext) String() string {
+	buf := make([]byte, 0, 55)
+	buf = append(buf, "00-"...)
+	buf = hex.AppendEncode(buf, s.TraceID[:])
+	buf = append(buf, '-')
+	buf = hex.AppendEncode(buf, s.SpanID[:])
+	buf = append(buf, '-')
+	buf = hex.AppendEncode(buf, []byte{s.Flags})
+	return string(buf)
+}
+
+// Attrs returns the trace_id, span_id and trace_flags attributes.
+func (s SpanContext) Attrs() []slog.Attr {
+	return []slog.Attr{
+		slog.String(TraceIDKey, hex.EncodeToString(s.TraceID[:])),
+		slog.String(SpanIDKey, hex.EncodeToString(s.SpanID[:])),
+		slog.String(TraceFlagsKey, hex.EncodeToString([]byte{s.Flags})),
+	}
+}
+
+// ParseTraceparent parses a W3C traceparent header value.  Values from
+// future versions are accepted, ignoring any trailing fields.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var result SpanContext
+
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return result, errors.NewCodeError(errors.CodeInvalidArgument, errInvalidTraceparent, "malformed")
+	}
+
+	var version [1]byte
+	if !decodeLowerHex(version[:], value[0:2]) || version[0] == 0xff {
+		return result, errors.NewCodeError(errors.CodeInvalidArgument, errInvalidTraceparent, "invalid version")
+	}
+	if version[0] == 0 && len(value) != 55 {
+		return result, errors.NewCodeError(errors.CodeInvalidArgument, errInvalidTraceparent, "malformed")
+	}
+	if len(value) > 55 && value[55] != '-' {
+		return result, errors.NewCodeError(errors.CodeInvalidArgument, errInvalidTraceparent, "malformed")
+	}
+
+	var flags [1]byte
+	if !decodeLowerHex(result.TraceID[:], value[3:35]) ||
+		!decodeLowerHex(result.SpanID[:], value[36:52]) ||
+		!decodeLowerHex(flags[:], value[53:55]) {
+		return SpanContext{}, errors.NewCodeError(errors.CodeInvalidArgument, errInvalidTraceparent, "invalid hex")
+	}
+	result.Flags = flags[0]
+
+	if !result.IsValid() {
+		return SpanContext{}, errors.NewCodeError(errors.CodeInvalidArgument, errInvalidTraceparent, "zero trace or span id")
+	}
+	return result, nil
+}
+
+// decodeLowerHex decodes src into dst, rejecting upper case digits as
+// required by the traceparent format
+func decodeLowerHex(dst []byte, src string) bool {
+	for i := 0; i < len(src); i++ {
+		if c := src[i]; 'A' <= c && c <= 'F' {
+			return false
+		}
+	}
+	n, err := hex.Decode(dst, []byte(src))
+	return err == nil && n == len(dst)
+}
+
+const contextKeySpanContext = contextKey("SpanContext")
+
+// ContextWithSpanContext attaches a span context to records logged with the
+// returned context.
+func ContextWithSpanContext(ctx context.Context, span SpanContext) context.Context {
+	return context.WithValue(ctx, contextKeySpanContext, span)
+}
+
+// SpanContextFromContext returns the span context attached by
+// ContextWithSpanContext, if any.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	span, ok := ctx.Value(contextKeySpanContext).(SpanContext)
+	return span, ok
+}
+
+// TraceExtractor returns the span context of ctx, allowing a tracing SDK
+// to supply its own active span.
+type TraceExtractor func(ctx context.Context) (SpanContext, bool)
+
+var registeredTraceExtractor TraceExtractor = SpanContextFromContext
+
+// RegisterTraceExtractor replaces the extractor used to correlate records
+// with traces.  Nil restores the default, SpanContextFromContext.
+func RegisterTraceExtractor(extractor TraceExtractor) {
+	if extractor == nil {
+		extractor = SpanContextFromContext
+	}
+	registeredTraceExtractor = extractor
+}
+
+// traceAttrsFromContext returns the trace correlation attributes for ctx,
+// which may be nil
+func traceAttrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	span, ok := registeredTraceExtractor(ctx)
+	if !ok || !span.IsValid() {
+		return nil
+	}
+	return span.Attrs()
+}
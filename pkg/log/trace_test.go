@@ -0,0 +1,71 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	span, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if assert.NoError(t, err) {
+		assert.Equal(t, byte(0x4b), span.TraceID[0])
+		assert.Equal(t, byte(0xb7), span.SpanID[7])
+		assert.True(t, span.Sampled())
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", span.String())
+	}
+
+	_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	assert.NoError(t, err)
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x",
+	} {
+		_, err := ParseTraceparent(value)
+		assert.ErrorIs(t, err, errInvalidTraceparent, value)
+	}
+}
+
+func TestFormattingLogger_TraceAttrs(t *testing.T) {
+	defer RegisterTraceExtractor(nil)
+
+	output := new(bytes.Buffer)
+	logger := &formattingLogger{
+		logger: slog.New(slog.NewTextHandler(output, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey && len(groups) == 0 {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})),
+	}
+
+	span, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithSpanContext(context.Background(), span)
+
+	logger.InfoContext(ctx, "traced")
+	logger.LogAttrs(ctx, LevelInfo, "attrs")
+	logger.Info("untraced")
+
+	RegisterTraceExtractor(func(context.Context) (SpanContext, bool) {
+		return SpanContext{TraceID: [16]byte{15: 1}, SpanID: [8]byte{7: 2}}, true
+	})
+	logger.InfoContext(context.Background(), "extracted")
+
+	assert.Equal(t, "level=INFO msg=traced trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01\n"+
+		"level=INFO msg=attrs trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 trace_flags=01\n"+
+		"level=INFO msg=untraced\n"+
+		"level=INFO msg=extracted trace_id=00000000000000000000000000000001 span_id=0000000000000002 trace_flags=00\n",
+		output.String())
+}